@@ -1,10 +1,19 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
 	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/letusgogo/quick/config"
 	"github.com/letusgogo/quick/logger"
@@ -21,6 +30,36 @@ type App struct {
 	opt     *Options
 	app     *cli.App
 	config  *config.Manager
+
+	// shared dependencies registered with Provide
+	providersMu sync.Mutex
+	providers   map[string]*provider
+	constructed []*provider
+
+	// completed initialization phases
+	phasesMu sync.Mutex
+	phases   []string
+
+	startedAt time.Time
+
+	// ctx is cancelled when the app exits
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// options from NewAppFromConfig, applied before the Init options
+	specOpts []Option
+
+	// built-in commands added by options, they check the app and never run components
+	builtinCommands []*cli.Command
+	// quietCommands are the built-in commands whose output is read from stdout, they run
+	// without config and logger initialization
+	quietCommands []*cli.Command
+
+	// components supervised by the app, see RegisterComponent
+	componentsMu      sync.Mutex
+	components        []*registeredComponent
+	componentsStopped int
+	componentStopErrs int
 }
 
 // NewApp creates a new application instance
@@ -49,20 +88,81 @@ func (a *App) Init(opts ...Option) {
 	}
 
 	a.opt = NewOptions()
-	for _, opt := range opts {
+	for _, opt := range append(a.specOpts, opts...) {
 		opt(a.opt)
 	}
+	if len(a.opt.RequiredKeys) > 0 {
+		a.config.AddValidator(func(m *config.Manager) error {
+			return m.RequireKeys(a.opt.RequiredKeys...)
+		})
+	}
+	for _, fn := range a.opt.ConfigValidators {
+		a.config.AddValidator(fn)
+	}
+	a.ctx, a.cancel = context.WithCancel(a.opt.Context)
 
 	a.app.Commands = a.opt.Commands
 	a.app.Flags = a.opt.Flags
 
+	// Add grouped and hidden commands
+	a.addCommandGroups()
+
+	// Add built-in commands
+	a.addBuiltinCommands()
+
+	// Guard commands that can be disabled by option or config
+	a.disableCommands(a.app.Commands)
+
+	// Run registered components after the command action
+	a.superviseComponents(a.app.Commands)
+
+	// Bound command runs by the run deadline
+	a.applyRunDeadline(a.app.Commands)
+
+	// Bind duration and size flags of commands into the config once they are parsed
+	a.bindCommandFlagValues(a.app.Commands)
+
 	// Add built-in flags
 	a.addBuiltinFlags()
 
+	// Complete flag values and add the completion command, after all flags and commands
+	a.setupCompletion()
+
 	// Set up before and after handlers
 	a.setupHandlers()
 }
 
+// checkBuiltinCommandNames returns an error if a user command has the name or alias of a
+// built-in command
+func (a *App) checkBuiltinCommandNames() error {
+	builtinNames := make(map[string]string)
+	for _, builtin := range a.builtinCommands {
+		for _, name := range builtin.Names() {
+			builtinNames[name] = builtin.Name
+		}
+	}
+	for _, command := range a.app.Commands {
+		if slices.Contains(a.builtinCommands, command) {
+			continue
+		}
+		for _, name := range command.Names() {
+			if builtin, ok := builtinNames[name]; ok {
+				return fmt.Errorf("command %q collides with the built-in %s command", name, builtin)
+			}
+		}
+	}
+	return nil
+}
+
+// builtinCommand returns the built-in command about to run, nil for user commands
+func (a *App) builtinCommand(c *cli.Context) *cli.Command {
+	command := c.App.Command(c.Args().First())
+	if command == nil || !slices.Contains(a.builtinCommands, command) {
+		return nil
+	}
+	return command
+}
+
 // addBuiltinFlags adds common flags that most applications need
 func (a *App) addBuiltinFlags() {
 	defaultConfig := "./config/default.yaml"
@@ -81,42 +181,160 @@ func (a *App) addBuiltinFlags() {
 		},
 		&cli.StringFlag{
 			Name:        "log.level",
-			Value:       "info",
-			DefaultText: "info",
-			Usage:       "log level (debug, info, warn, error)",
+			Value:       a.opt.LogLevel,
+			DefaultText: a.opt.LogLevel,
+			Usage:       "log level (trace, debug, info, warn, error)",
 			Required:    false,
 		},
 		&cli.StringFlag{
 			Name:        "log.format",
-			Value:       "text",
-			DefaultText: "text",
-			Usage:       "log format (text, json)",
+			Value:       a.opt.LogFormat,
+			DefaultText: a.opt.LogFormat,
+			Usage:       "log format (" + strings.Join(logFormats, ", ") + ")",
 			Required:    false,
 		},
 		&cli.StringFlag{
 			Name:        "env",
-			Value:       "dev",
-			DefaultText: "dev",
-			Usage:       "environment (dev, test, prod)",
+			Value:       a.opt.Environments[0],
+			DefaultText: a.opt.Environments[0],
+			Usage:       "environment (" + strings.Join(a.opt.Environments, ", ") + ")",
 			Required:    false,
 		},
+		&cli.BoolFlag{
+			Name:  checkFlag,
+			Usage: "initialize and validate everything, then exit without running a command",
+		},
 	}
 
 	a.app.Flags = append(a.app.Flags, builtinFlags...)
 }
 
+// addCommandGroups adds commands from the command group and hidden command options
+func (a *App) addCommandGroups() {
+	categories := make([]string, 0, len(a.opt.CommandGroups))
+	for category := range a.opt.CommandGroups {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		for _, command := range a.opt.CommandGroups[category] {
+			command.Category = category
+			a.app.Commands = append(a.app.Commands, command)
+		}
+	}
+
+	for _, command := range a.opt.HiddenCommands {
+		command.Hidden = true
+		a.app.Commands = append(a.app.Commands, command)
+	}
+}
+
+// addBuiltinCommands adds the optional built-in commands enabled by options
+func (a *App) addBuiltinCommands() {
+	if a.opt.ValidateCommand {
+		a.builtinCommands = append(a.builtinCommands, a.validateCommand())
+	}
+	if a.opt.HealthCheckAddr != "" {
+		a.builtinCommands = append(a.builtinCommands, a.healthCheckCommand())
+	}
+	flags, plan := a.flagsCommand(), a.planCommand()
+	a.quietCommands = append(a.quietCommands, flags, plan)
+	a.builtinCommands = append(a.builtinCommands, flags, plan)
+	a.app.Commands = append(a.app.Commands, a.builtinCommands...)
+}
+
+// validateCommand returns a command that validates the config file without starting anything
+func (a *App) validateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "validate",
+		Usage: "validate the config file and exit",
+		Action: func(c *cli.Context) error {
+			out := c.App.Writer
+			configFile := c.String("config")
+			fmt.Fprintf(out, "Validating config file: %s\n", configFile)
+
+			if configFile == "" {
+				fmt.Fprintln(out, "  [FAIL] no config file specified")
+				return cli.Exit("config validation failed", 1)
+			}
+			if err := a.config.LoadFromFile(configFile); err != nil {
+				fmt.Fprintf(out, "  [FAIL] load: %v\n", err)
+				return cli.Exit("config validation failed", 1)
+			}
+			fmt.Fprintln(out, "  [OK]   load")
+
+			if a.opt.ValidateSchema != nil {
+				schemaType := reflect.TypeOf(a.opt.ValidateSchema)
+				if schemaType.Kind() == reflect.Ptr {
+					schemaType = schemaType.Elem()
+				}
+				schema := reflect.New(schemaType).Interface()
+
+				err := a.config.UnmarshalAndValidate(schema)
+				var validationErr *config.ValidationError
+				switch {
+				case errors.As(err, &validationErr):
+					for _, field := range validationErr.Fields {
+						fmt.Fprintf(out, "  [FAIL] %s\n", field)
+					}
+					return cli.Exit("config validation failed", 1)
+				case err != nil:
+					fmt.Fprintf(out, "  [FAIL] unmarshal: %v\n", err)
+					return cli.Exit("config validation failed", 1)
+				}
+				fmt.Fprintln(out, "  [OK]   schema")
+			}
+
+			if err := a.config.Validate(); err != nil {
+				for _, line := range strings.Split(err.Error(), "\n") {
+					fmt.Fprintf(out, "  [FAIL] %s\n", line)
+				}
+				return cli.Exit("config validation failed", 1)
+			}
+			fmt.Fprintln(out, "  [OK]   validators")
+
+			fmt.Fprintln(out, "Config is valid")
+			return nil
+		},
+	}
+}
+
 // setupHandlers sets up before and after handlers
 func (a *App) setupHandlers() {
 	a.app.Before = func(c *cli.Context) error {
+		a.startedAt = time.Now()
+
+		// completion scripts, flag docs and the shutdown plan are read from stdout,
+		// so nothing may be logged
+		builtin := a.builtinCommand(c)
+		if slices.Contains(a.quietCommands, builtin) {
+			return nil
+		}
+
 		// Initialize configuration
 		if err := a.initConfig(c); err != nil {
 			return err
 		}
+		a.bindFlagValues(c)
+		if _, err := a.enumFlag(c, "env", a.opt.Environments); err != nil {
+			return err
+		}
+		// the validate command reports failing validators itself
+		validateRun := builtin != nil && builtin.Name == "validate"
+		if err := a.config.Validate(); err != nil && !validateRun {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		a.markPhase(PhaseConfig)
 
 		// Initialize logger
 		if err := a.initLogger(c); err != nil {
 			return err
 		}
+		a.markPhase(PhaseLogger)
+
+		// Report config keys the app does not declare, now that the logger is set up
+		a.warnUnusedKeys()
 
 		// Run user-defined before functions
 		for _, before := range a.opt.Before {
@@ -124,24 +342,69 @@ func (a *App) setupHandlers() {
 				return err
 			}
 		}
+		a.markPhase(PhaseHooks)
+
+		// Check mode stops here, the config, logger and hooks are set up by now
+		if c.Bool(checkFlag) {
+			if err := a.runComponentChecks(c); err != nil {
+				return fmt.Errorf("check failed: %w", err)
+			}
+			fmt.Fprintln(c.App.Writer, "Check passed")
+			return errCheckPassed
+		}
+
+		if a.opt.StartupManifest != "" {
+			if err := a.writeManifest(a.opt.StartupManifest); err != nil {
+				a.log.Warnf("Failed to write startup manifest: %v", err)
+			}
+		}
 
 		return nil
 	}
 
 	a.app.After = func(c *cli.Context) error {
-		// Run user-defined after functions
+		start := time.Now()
+
+		// Run user-defined after functions, an error skips the remaining ones
+		var hookErr error
 		for _, after := range a.opt.After {
-			if err := after(c); err != nil {
-				return err
+			if hookErr = after(c); hookErr != nil {
+				a.log.Errorf("After hook failed: %v", hookErr)
+				break
 			}
 		}
-		return nil
+
+		// Stop workers, the config watcher and close shared dependencies even if an after
+		// function fails
+		a.cancel()
+		a.config.StopWatching()
+		closed, closeErrs := a.closeProviders()
+
+		closed += a.componentsStopped
+		errCount := len(closeErrs) + a.componentStopErrs
+		if hookErr != nil {
+			errCount++
+		}
+		a.logShutdownSummary(closed, errCount, time.Since(start))
+		return hookErr
 	}
 }
 
+// logShutdownSummary logs one greppable line at the end of shutdown, at error level if anything failed
+func (a *App) logShutdownSummary(closed, errCount int, took time.Duration) {
+	msg := fmt.Sprintf("shutdown complete: %d components stopped, %d errors, took %s",
+		closed, errCount, took.Round(time.Millisecond))
+	if errCount > 0 {
+		a.log.Error(msg)
+		return
+	}
+	a.log.Info(msg)
+}
+
 // initConfig initializes configuration management
 func (a *App) initConfig(c *cli.Context) error {
 	// Setup environment variable overrides using Viper's built-in support
+	a.config.SetStrictEnv(a.opt.StrictEnv)
 	a.config.SetupEnvironmentOverrides()
 
 	// Set environment prefix if specified in options
@@ -162,44 +425,81 @@ func (a *App) initConfig(c *cli.Context) error {
 	}
 
 	// Bind common environment variables that don't follow the standard pattern
-	commonBindings := map[string]string{
-		"log.level":  "LOG_LEVEL",
-		"log.format": "LOG_FORMAT",
-		"env":        "ENV",
-	}
-	a.config.BindEnvs(commonBindings)
+	a.config.BindEnvs(commonEnvBindings)
 
 	return nil
 }
 
-// initLogger initializes the logger
-func (a *App) initLogger(c *cli.Context) error {
-	// Get log configuration from CLI flags or config file
-	logLevel := c.String("log.level")
-	if logLevel == "" {
-		logLevel = a.config.GetString("log.level")
+// builtinConfigKeys are the config keys read by the app itself
+var builtinConfigKeys = []string{"log", "env", disabledCommandsKey, config.IncludeKey}
+
+// warnUnusedKeys logs config file keys not covered by WithConfigSchema or built-in keys
+func (a *App) warnUnusedKeys() {
+	if len(a.opt.KnownConfigKeys) == 0 {
+		return
 	}
-	if logLevel == "" {
-		logLevel = "info"
+
+	known := append([]string{}, builtinConfigKeys...)
+	known = append(known, a.opt.KnownConfigKeys...)
+	for _, flag := range a.app.Flags {
+		known = append(known, flag.Names()...)
+	}
+	for _, key := range a.config.UnusedKeys(known) {
+		a.log.Warnf("Unused config key: %s", key)
 	}
+}
+
+// Valid values of the built-in enum flags
+var (
+	logLevels  = []string{"trace", "debug", "info", "warn", "warning", "error", "fatal", "panic"}
+	logFormats = []string{"text", "json"}
+)
 
-	logFormat := c.String("log.format")
-	if logFormat == "" {
-		logFormat = a.config.GetString("log.format")
+// enumFlag resolves a built-in enum flag: an explicitly set flag wins over the config file
+// and env vars, which win over the flag default. Invalid values fail with the valid ones listed.
+func (a *App) enumFlag(c *cli.Context, name string, allowed []string) (string, error) {
+	if c.IsSet(name) {
+		a.config.Set(name, c.String(name))
 	}
-	if logFormat == "" {
-		logFormat = "text"
+	return a.config.GetEnum(name, allowed, c.String(name))
+}
+
+// initLogger initializes the logger
+func (a *App) initLogger(c *cli.Context) error {
+	// Get log configuration from CLI flags or config file
+	logLevel, err := a.enumFlag(c, "log.level", logLevels)
+	if err != nil {
+		return err
+	}
+	logFormat, err := a.enumFlag(c, "log.format", logFormats)
+	if err != nil {
+		return err
 	}
 
 	// Initialize logger
 	loggerConfig := logger.Config{
-		Level:  logLevel,
-		Format: logFormat,
+		Level:      logLevel,
+		Format:     logFormat,
+		File:       a.config.GetString("log.file"),
+		MaxSizeMB:  a.config.GetInt("log.max_size_mb"),
+		MaxBackups: a.config.GetInt("log.max_backups"),
+		MaxAgeDays: a.config.GetInt("log.max_age_days"),
+		Compress:   a.config.GetBool("log.compress"),
+		TeeStdout:  a.config.GetBool("log.tee_stdout"),
+	}
+	if a.config.Viper().IsSet("log.outputs") {
+		if err := a.config.UnmarshalKey("log.outputs", &loggerConfig.Outputs); err != nil {
+			return fmt.Errorf("invalid log.outputs: %w", err)
+		}
+		if len(loggerConfig.Outputs) == 0 {
+			return errors.New("log.outputs must define at least one output")
+		}
 	}
 
 	options := logger.InitOptions{
-		ReportCaller: true,
-		AddTimestamp: true,
+		ReportCaller:  true,
+		AddTimestamp:  true,
+		SchemaVersion: a.config.GetString("log.schema_version"),
 	}
 
 	return logger.InitWithOptions(loggerConfig, options)
@@ -211,7 +511,16 @@ func (a *App) Start() error {
 		panic("please call Init() first")
 	}
 
-	err := a.app.Run(os.Args)
+	// the cli rejects duplicated command names too, without naming the built-in
+	if err := a.checkBuiltinCommandNames(); err != nil {
+		a.log.Fatal(err)
+		return err
+	}
+
+	err := a.app.RunContext(a.ctx, os.Args)
+	if errors.Is(err, errCheckPassed) {
+		return nil
+	}
 	if err != nil {
 		a.log.Fatal(err)
 		return err
@@ -220,6 +529,15 @@ func (a *App) Start() error {
 	return nil
 }
 
+// Context returns the app context, passed to commands as cli.Context.Context.
+// It is cancelled when the app exits or the parent context from WithContext is cancelled.
+func (a *App) Context() context.Context {
+	if a.ctx == nil {
+		panic("please call Init() first")
+	}
+	return a.ctx
+}
+
 // Config returns the configuration manager
 func (a *App) Config() *config.Manager {
 	if a.config == nil {
@@ -228,24 +546,109 @@ func (a *App) Config() *config.Manager {
 	return a.config
 }
 
-// WaitForSignal waits for termination signals and calls the provided function
-func WaitForSignal(stopFunc func(os.Signal)) {
-	signalChan := make(chan os.Signal, 1)
+// WaitForSignal waits for a termination signal and calls stopFunc with it. It returns the
+// received signal and the error of stopFunc; a panic in stopFunc is returned as an error.
+func WaitForSignal(stopFunc func(os.Signal) error) (os.Signal, error) {
+	return WaitForSignalOrContext(context.Background(), stopFunc)
+}
 
-	signal.Notify(signalChan,
-		os.Interrupt,
-		syscall.SIGHUP,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT)
+// ContextDone is passed to the stop function of WaitForSignalOrContext when ctx is done
+var ContextDone os.Signal = contextDoneSignal{}
+
+// contextDoneSignal is the os.Signal type of ContextDone
+type contextDoneSignal struct{}
+
+func (contextDoneSignal) String() string { return "context done" }
+func (contextDoneSignal) Signal()        {}
+
+// WaitForSignalOrContext is WaitForSignal that also returns once ctx is done, for apps run by
+// a supervisor cancelling a context instead of sending signals. stopFunc is then called with
+// ContextDone, so the same stop logic runs either way.
+func WaitForSignalOrContext(ctx context.Context, stopFunc func(os.Signal) error) (recvSignal os.Signal, err error) {
+	signalChan := make(chan os.Signal, 1)
+	notifyTermination(signalChan)
+	defer signal.Stop(signalChan)
 
 	defer func() {
 		if e := recover(); e != nil {
 			logrus.Errorf("crashed, err: %s stack:%s", e, string(debug.Stack()))
+			err = fmt.Errorf("stop function panicked: %v", e)
 		}
 	}()
 
-	recvSignal := <-signalChan
-	logrus.Infof("received signal: %v", recvSignal)
-	stopFunc(recvSignal)
+	select {
+	case recvSignal = <-signalChan:
+		logrus.Infof("received signal: %v", recvSignal)
+	case <-ctx.Done():
+		recvSignal = ContextDone
+		logrus.Infof("context done: %v", ctx.Err())
+	}
+	return recvSignal, stopFunc(recvSignal)
+}
+
+// ShutdownContext describes a received termination signal
+type ShutdownContext struct {
+	// Signal is the received signal
+	Signal os.Signal
+	// ReceivedAt is the time the signal was received
+	ReceivedAt time.Time
+	// Count is the number of termination signals received so far, starting at 1
+	Count int
+	// Repeat is true when a previous stop callback is still running
+	Repeat bool
+}
+
+// WaitForSignalContext waits for termination signals and calls stopFunc with a ShutdownContext.
+// Signals received while stopFunc is still running call it again with Repeat set, so
+// handlers can escalate, e.g. exit immediately on a second SIGTERM.
+// It returns once the first stopFunc call returns.
+func WaitForSignalContext(stopFunc func(ShutdownContext)) {
+	signalChan := make(chan os.Signal, 1)
+	notifyTermination(signalChan)
+	defer signal.Stop(signalChan)
+
+	done := make(chan struct{})
+	runStop := func(sc ShutdownContext) {
+		defer func() {
+			if e := recover(); e != nil {
+				logrus.Errorf("crashed, err: %s stack:%s", e, string(debug.Stack()))
+			}
+		}()
+		stopFunc(sc)
+	}
+
+	count := 0
+	for {
+		select {
+		case recvSignal := <-signalChan:
+			count++
+			logrus.Infof("received signal: %v (count %d)", recvSignal, count)
+			sc := ShutdownContext{
+				Signal:     recvSignal,
+				ReceivedAt: time.Now(),
+				Count:      count,
+				Repeat:     count > 1,
+			}
+			if count == 1 {
+				go func() {
+					defer close(done)
+					runStop(sc)
+				}()
+			} else {
+				go runStop(sc)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// notifyTermination relays termination signals to signalChan
+func notifyTermination(signalChan chan os.Signal) {
+	signal.Notify(signalChan,
+		os.Interrupt,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
 }
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/letusgogo/quick/logger"
+	"github.com/urfave/cli/v2"
+)
+
+// watching reports whether a config watcher goroutine is running
+func watching() bool {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	// a goroutine not scheduled yet only shows the Watch call creating it
+	return strings.Contains(string(buf[:n]), "config.(*Manager).Watch")
+}
+
+func TestAfterStopsConfigWatcher(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("feature: on\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var a *App
+	a = newTestApp(t, WithCommands([]*cli.Command{{
+		Name: "serve",
+		Action: func(c *cli.Context) error {
+			if err := a.Config().Watch(func(e fsnotify.Event) {}); err != nil {
+				t.Fatalf("Failed to watch config: %v", err)
+			}
+			if !watching() {
+				t.Error("Expected the config watcher to run during the command")
+			}
+			return nil
+		},
+	}}))
+
+	if err := a.app.RunContext(a.ctx, []string{"test", "--config", configFile, "serve"}); err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	if watching() {
+		t.Error("Expected the config watcher to be stopped on shutdown")
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	type schema struct {
+		Server struct {
+			Port int `mapstructure:"port" validate:"required"`
+		} `mapstructure:"server"`
+	}
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(valid, []byte("server:\n  port: 8080\ndatabase:\n  url: postgres://db\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(invalid, []byte("server:\n  host: localhost\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		configFile string
+		wantErr    bool
+		contains   string
+	}{
+		{valid, false, "Config is valid"},
+		{invalid, true, "[FAIL] server.port"},
+		{filepath.Join(dir, "missing.yaml"), true, "[FAIL] load"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		a := newTestApp(t, WithValidateCommand(schema{}), WithRequiredKeys("database.url"))
+		a.app.Writer = &out
+		a.app.ExitErrHandler = func(*cli.Context, error) {}
+
+		err := a.app.RunContext(a.ctx, []string{"test", "--config", tt.configFile, "validate"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.configFile, tt.wantErr, err)
+		}
+		if !strings.Contains(out.String(), tt.contains) {
+			t.Errorf("%s: expected report to contain %q, got %q", tt.configFile, tt.contains, out.String())
+		}
+	}
+}
+
+func TestCommandGroups(t *testing.T) {
+	serve := &cli.Command{Name: "serve", Usage: "serve requests"}
+	migrate := &cli.Command{Name: "migrate", Usage: "migrate the database"}
+	debug := &cli.Command{Name: "debug", Usage: "internal debugging"}
+	a := newTestApp(t,
+		WithCommands([]*cli.Command{serve}),
+		WithCommandGroups(map[string][]*cli.Command{"database": {migrate}}),
+		WithHiddenCommands([]*cli.Command{debug}),
+	)
+
+	if a.app.Command("serve") != serve || serve.Category != "" {
+		t.Errorf("Expected serve to stay an uncategorized command")
+	}
+	if a.app.Command("migrate") != migrate || migrate.Category != "database" {
+		t.Errorf("Expected migrate in the database category, got %q", migrate.Category)
+	}
+	if a.app.Command("debug") != debug || !debug.Hidden {
+		t.Errorf("Expected debug to be a hidden command")
+	}
+
+	var out bytes.Buffer
+	a.app.Writer = &out
+	if err := a.app.RunContext(a.ctx, []string{"test", "--help"}); err != nil {
+		t.Fatalf("Failed to print help: %v", err)
+	}
+	if help := out.String(); !strings.Contains(help, "database:") || strings.Contains(help, "internal debugging") {
+		t.Errorf("Expected help with the database category and without debug, got %q", help)
+	}
+}
+
+func TestInvalidEnumFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		contains string
+	}{
+		{[]string{"--log.level", "verbose"}, "trace, debug, info"},
+		{[]string{"--log.format", "xml"}, "text, json"},
+		{[]string{"--env", "staging"}, "dev, test, prod"},
+	}
+	for _, tt := range tests {
+		a := newTestApp(t, WithCommands([]*cli.Command{{Name: "serve", Action: func(c *cli.Context) error { return nil }}}))
+		args := append(append([]string{"test", "--config", ""}, tt.args...), "serve")
+		err := a.app.RunContext(a.ctx, args)
+		if err == nil || !strings.Contains(err.Error(), tt.contains) {
+			t.Errorf("%v: expected an error listing %q, got %v", tt.args, tt.contains, err)
+		}
+	}
+}
+
+func TestShutdownSummary(t *testing.T) {
+	a := newTestApp(t)
+	buf, restore := logger.CaptureForTest()
+	defer restore()
+
+	a.logShutdownSummary(3, 0, 2400*time.Millisecond)
+	a.logShutdownSummary(1, 2, time.Second)
+
+	entries, err := logger.ParseCaptured(buf)
+	if err != nil {
+		t.Fatalf("Failed to parse log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 summary lines, got %d", len(entries))
+	}
+	if entries[0].Level != "info" || entries[0].Message != "shutdown complete: 3 components stopped, 0 errors, took 2.4s" {
+		t.Errorf("Unexpected summary: %s %q", entries[0].Level, entries[0].Message)
+	}
+	if entries[1].Level != "error" || !strings.Contains(entries[1].Message, "2 errors") {
+		t.Errorf("Expected an error level summary with errors, got %s %q", entries[1].Level, entries[1].Message)
+	}
+}
+
+func TestWaitForSignal(t *testing.T) {
+	// keep SIGTERM from killing the test until WaitForSignal listens for it
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer signal.Stop(guard)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				syscall.Kill(os.Getpid(), syscall.SIGTERM)
+			}
+		}
+	}()
+
+	stopErr := errors.New("stop failed")
+	sig, err := WaitForSignal(func(s os.Signal) error { return stopErr })
+	close(done)
+	wg.Wait()
+
+	if sig != syscall.SIGTERM {
+		t.Errorf("Expected SIGTERM, got %v", sig)
+	}
+	if !errors.Is(err, stopErr) {
+		t.Errorf("Expected the stop function error, got %v", err)
+	}
+}
+
+func TestWaitForSignalOrContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var received os.Signal
+	sig, err := WaitForSignalOrContext(ctx, func(s os.Signal) error {
+		received = s
+		return nil
+	})
+	if sig != ContextDone || received != ContextDone || err != nil {
+		t.Errorf("Expected ContextDone passed to the stop function, got %v, %v, %v", sig, received, err)
+	}
+
+	_, err = WaitForSignalOrContext(ctx, func(s os.Signal) error { panic("boom") })
+	if err == nil || !strings.Contains(err.Error(), "panicked: boom") {
+		t.Errorf("Expected the panic returned as an error, got %v", err)
+	}
+}
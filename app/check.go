@@ -0,0 +1,41 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// checkFlag is the built-in flag running the app in check mode
+const checkFlag = "check"
+
+// errCheckPassed stops the cli before dispatching to a command once check mode succeeded
+var errCheckPassed = errors.New("check passed")
+
+// runComponentChecks calls Check on the registered components implementing Checker. Check mode
+// returns before any command action runs, so only components registered before Start or by
+// before hooks (AddBefore) are checked.
+func (a *App) runComponentChecks(c *cli.Context) error {
+	a.componentsMu.Lock()
+	components := append([]*registeredComponent{}, a.components...)
+	a.componentsMu.Unlock()
+	if len(components) == 0 {
+		fmt.Fprintln(c.App.Writer, "  [WARN] no components registered, register them before Start or in an AddBefore hook to check them")
+	}
+
+	var errs []error
+	for _, registered := range components {
+		checker, ok := registered.component.(Checker)
+		if !ok {
+			continue
+		}
+		if err := checker.Check(c.Context); err != nil {
+			fmt.Fprintf(c.App.Writer, "  [FAIL] component %s: %v\n", registered.name, err)
+			errs = append(errs, fmt.Errorf("check component %q: %w", registered.name, err))
+			continue
+		}
+		fmt.Fprintf(c.App.Writer, "  [OK]   component %s\n", registered.name)
+	}
+	return errors.Join(errs...)
+}
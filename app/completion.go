@@ -0,0 +1,190 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// completionCommandName is the name of the hidden command printing completion scripts
+const completionCommandName = "completion"
+
+// bashCompletionScript is the bash completion script of urfave/cli, %[1]s is the program name
+// and %[2]s its name as shell function name part
+const bashCompletionScript = `#!/bin/bash
+
+_%[2]s_bash_autocomplete() {
+  if [[ "${COMP_WORDS[0]}" != "source" ]]; then
+    local cur opts base words
+    COMPREPLY=()
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    words=("${COMP_WORDS[@]:0:$COMP_CWORD}")
+    if [[ "$cur" == "-"* ]]; then
+      requestComp="${words[*]} ${cur} --generate-bash-completion"
+    else
+      requestComp="${words[*]} --generate-bash-completion"
+    fi
+    opts=$(eval "${requestComp}" 2>/dev/null)
+    COMPREPLY=($(compgen -W "${opts}" -- ${cur}))
+    return 0
+  fi
+}
+
+complete -o bashdefault -o default -o nospace -F _%[2]s_bash_autocomplete %[1]s
+`
+
+// zshCompletionScript is the zsh completion script of urfave/cli, with the arguments of
+// bashCompletionScript
+const zshCompletionScript = `#compdef %[1]s
+
+_%[2]s_zsh_autocomplete() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+
+compdef _%[2]s_zsh_autocomplete %[1]s
+`
+
+// builtinCompleters complete the values of the built-in flags. The config flag has none,
+// so shells fall back to completing file paths.
+func (a *App) builtinCompleters() map[string]func() []string {
+	return map[string]func() []string{
+		"env":        func() []string { return a.opt.Environments },
+		"log.level":  func() []string { return logLevels },
+		"log.format": func() []string { return logFormats },
+	}
+}
+
+// setupCompletion hooks the flag value completers into the shell completion of the app
+// and all commands, and adds the hidden completion command
+func (a *App) setupCompletion() {
+	completers := a.builtinCompleters()
+	for name, completer := range a.opt.Completions {
+		completers[name] = completer
+	}
+
+	complete := a.app.BashComplete
+	if complete == nil {
+		complete = cli.DefaultAppComplete
+	}
+	a.app.BashComplete = func(c *cli.Context) {
+		if !completeFlagValue(c, completers, a.app.Flags) {
+			complete(c)
+		}
+	}
+	a.setupCommandCompletion(a.app.Commands, completers)
+
+	completion := a.completionCommand()
+	a.builtinCommands = append(a.builtinCommands, completion)
+	a.quietCommands = append(a.quietCommands, completion)
+	a.app.Commands = append(a.app.Commands, completion)
+}
+
+// setupCommandCompletion hooks the flag value completers into the given commands
+func (a *App) setupCommandCompletion(commands []*cli.Command, completers map[string]func() []string) {
+	for _, command := range commands {
+		a.setupCommandCompletion(command.Subcommands, completers)
+
+		complete := command.BashComplete
+		if complete == nil {
+			complete = cli.DefaultCompleteWithFlags(command)
+		}
+		flags := append(append([]cli.Flag{}, command.Flags...), a.app.Flags...)
+		command.BashComplete = func(c *cli.Context) {
+			if !completeFlagValue(c, completers, flags) {
+				complete(c)
+			}
+		}
+	}
+}
+
+// completeFlagValue prints the value completions if the word before the one being completed
+// is a flag taking a value. A flag without completer prints nothing, so the shell completes
+// file paths. It reports whether the completion was handled.
+func completeFlagValue(c *cli.Context, completers map[string]func() []string, flags []cli.Flag) bool {
+	// like cli.DefaultCompleteWithFlags, the completion flag is the last of os.Args
+	if len(os.Args) < 3 {
+		return false
+	}
+	lastArg := os.Args[len(os.Args)-2]
+	if !strings.HasPrefix(lastArg, "-") || strings.Contains(lastArg, "=") {
+		return false
+	}
+	name := strings.TrimLeft(lastArg, "-")
+
+	flag := lookupFlag(flags, name)
+	if flag == nil {
+		return false
+	}
+	if valueFlag, ok := flag.(cli.DocGenerationFlag); !ok || !valueFlag.TakesValue() {
+		return false
+	}
+
+	// completers are registered by the flag's main name
+	if completer, ok := completers[flag.Names()[0]]; ok {
+		for _, value := range completer() {
+			fmt.Fprintln(c.App.Writer, value)
+		}
+	}
+	return true
+}
+
+// lookupFlag returns the flag with the given name or alias
+func lookupFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		for _, flagName := range flag.Names() {
+			if flagName == name {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+// completionCommand returns the hidden command printing the shell completion scripts
+func (a *App) completionCommand() *cli.Command {
+	return &cli.Command{
+		Name:      completionCommandName,
+		Usage:     "print the shell completion script (bash, zsh or fish)",
+		ArgsUsage: "bash|zsh|fish",
+		Hidden:    true,
+		Action: func(c *cli.Context) error {
+			out := c.App.Writer
+			funcName := strings.Map(func(r rune) rune {
+				if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+					return r
+				}
+				return '_'
+			}, c.App.Name)
+			switch shell := c.Args().First(); shell {
+			case "bash":
+				fmt.Fprintf(out, bashCompletionScript, c.App.Name, funcName)
+			case "zsh":
+				fmt.Fprintf(out, zshCompletionScript, c.App.Name, funcName)
+			case "fish":
+				script, err := c.App.ToFishCompletion()
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(out, script)
+			default:
+				return cli.Exit(fmt.Sprintf("unsupported shell %q, use bash, zsh or fish", shell), 1)
+			}
+			return nil
+		},
+	}
+}
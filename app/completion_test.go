@@ -0,0 +1,75 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestCompletionCommand(t *testing.T) {
+	tests := []struct {
+		shell    string
+		contains string
+	}{
+		{"bash", "complete -o bashdefault -o default -o nospace -F _my_app_bash_autocomplete my-app"},
+		{"zsh", "compdef _my_app_zsh_autocomplete my-app"},
+		{"fish", "complete -c my-app"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		a := NewApp("my-app", "test app")
+		a.Init()
+		a.app.Writer = &out
+		if err := a.app.RunContext(a.ctx, []string{"my-app", "completion", tt.shell}); err != nil {
+			t.Errorf("%s: failed to print the completion script: %v", tt.shell, err)
+		}
+		if !strings.Contains(out.String(), tt.contains) {
+			t.Errorf("%s: expected the script to contain %q, got %q", tt.shell, tt.contains, out.String())
+		}
+	}
+
+	a := newTestApp(t)
+	a.app.ExitErrHandler = func(*cli.Context, error) {}
+	if err := a.app.RunContext(a.ctx, []string{"test", "completion", "csh"}); err == nil {
+		t.Error("Expected an error for an unsupported shell")
+	}
+}
+
+func TestCompleteFlagValues(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"test", "--env", "--generate-bash-completion"}, "dev\ntest\nprod\n"},
+		{[]string{"test", "serve", "--region", "--generate-bash-completion"}, "eu-west\nus-east\n"},
+		// no completer, the shell completes file paths
+		{[]string{"test", "serve", "--listen", "--generate-bash-completion"}, ""},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		serve := &cli.Command{
+			Name:   "serve",
+			Flags:  []cli.Flag{&cli.StringFlag{Name: "region"}, &cli.StringFlag{Name: "listen"}},
+			Action: func(c *cli.Context) error { return nil },
+		}
+		a := newTestApp(t,
+			WithCommands([]*cli.Command{serve}),
+			WithCompletions(map[string]func() []string{"region": func() []string { return []string{"eu-west", "us-east"} }}),
+		)
+		a.app.Writer = &out
+
+		args := os.Args
+		os.Args = tt.args
+		err := a.app.RunContext(a.ctx, tt.args)
+		os.Args = args
+		if err != nil {
+			t.Errorf("%v: failed to complete: %v", tt.args, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("%v: expected completions %q, got %q", tt.args, tt.want, out.String())
+		}
+	}
+}
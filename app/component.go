@@ -0,0 +1,223 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// defaultShutdownTimeout bounds stopping all components unless WithShutdownTimeout is given
+const defaultShutdownTimeout = 30 * time.Second
+
+// Component is a long running part of the app, e.g. an HTTP server, supervised by the app
+type Component interface {
+	// Start starts the component and returns once it is running, long running work
+	// belongs in goroutines ended by Stop
+	Start(ctx context.Context) error
+	// Stop stops the component, returning early when ctx is done
+	Stop(ctx context.Context) error
+}
+
+// Checker is implemented by components that can verify their wiring without starting,
+// e.g. by pinging a dependency. Check is called on registered components in check mode.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Restartable is implemented by components that can be started again after Stop; only they
+// can be restarted with RestartComponent
+type Restartable interface {
+	Component
+	// CanRestart reports whether Start may be called again after Stop
+	CanRestart() bool
+}
+
+// registeredComponent is a component with its registration name
+type registeredComponent struct {
+	name string
+
+	// mu serializes starting and stopping the component, it guards component and started
+	mu        sync.Mutex
+	component Component
+	started   bool
+	// restarting rejects a restart while another one is in progress
+	restarting atomic.Bool
+}
+
+// RegisterComponent registers a component supervised by the app. Once the running command's
+// action returns without error, components are started in registration order; the app then
+// waits for a termination signal (or the command context to be done) and stops them in
+// reverse order within the shutdown timeout. Registering a name again replaces the component;
+// a running component is stopped first within the shutdown timeout, and its replacement is
+// not started.
+func (a *App) RegisterComponent(name string, c Component) {
+	a.componentsMu.Lock()
+	defer a.componentsMu.Unlock()
+
+	for _, registered := range a.components {
+		if registered.name == name {
+			registered.mu.Lock()
+			defer registered.mu.Unlock()
+			if registered.started {
+				a.log.Warnf("Component %s replaced while running, stopping it", name)
+				ctx, cancel := context.WithTimeout(context.Background(), a.opt.ShutdownTimeout)
+				defer cancel()
+				if err := registered.component.Stop(ctx); err != nil {
+					a.log.Errorf("Failed to stop replaced component %s: %v", name, err)
+				}
+				registered.started = false
+			}
+			registered.component = c
+			return
+		}
+	}
+	a.components = append(a.components, &registeredComponent{name: name, component: c})
+}
+
+// superviseComponents wraps the actions of all commands to run the registered components
+// once the action returned
+func (a *App) superviseComponents(commands []*cli.Command) {
+	for _, command := range commands {
+		a.superviseComponents(command.Subcommands)
+
+		if command.Action == nil || slices.Contains(a.builtinCommands, command) {
+			continue
+		}
+
+		action := command.Action
+		command.Action = func(c *cli.Context) error {
+			if err := action(c); err != nil {
+				return err
+			}
+			return a.runComponents(c.Context)
+		}
+	}
+}
+
+// runComponents starts the registered components, waits for a termination signal or ctx,
+// then stops them
+func (a *App) runComponents(ctx context.Context) error {
+	a.componentsMu.Lock()
+	components := append([]*registeredComponent{}, a.components...)
+	a.componentsMu.Unlock()
+	if len(components) == 0 {
+		return nil
+	}
+
+	// Relay signals from before the components are started too
+	signalChan := make(chan os.Signal, 1)
+	notifyTermination(signalChan)
+	defer signal.Stop(signalChan)
+
+	for _, registered := range components {
+		registered.mu.Lock()
+		err := registered.component.Start(ctx)
+		registered.started = err == nil
+		registered.mu.Unlock()
+
+		if err != nil {
+			a.log.Errorf("Failed to start component %s: %v", registered.name, err)
+			stopErr := a.stopComponents(components)
+			return errors.Join(fmt.Errorf("start component %q: %w", registered.name, err), stopErr)
+		}
+		a.log.Infof("Component %s started", registered.name)
+	}
+
+	select {
+	case s := <-signalChan:
+		a.log.Infof("Received signal %v, stopping %d components", s, len(components))
+	case <-ctx.Done():
+		a.log.Infof("Context done (%v), stopping %d components", ctx.Err(), len(components))
+	}
+	return a.stopComponents(components)
+}
+
+// stopComponents stops the started components in reverse order, all within the shutdown timeout
+func (a *App) stopComponents(components []*registeredComponent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.opt.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		registered := components[i]
+		registered.mu.Lock()
+		if !registered.started {
+			registered.mu.Unlock()
+			continue
+		}
+		registered.started = false
+
+		start := time.Now()
+		err := registered.component.Stop(ctx)
+		registered.mu.Unlock()
+		a.componentsStopped++
+		if err != nil {
+			a.log.Errorf("Failed to stop component %s: %v", registered.name, err)
+			errs = append(errs, fmt.Errorf("stop component %q: %w", registered.name, err))
+			continue
+		}
+		a.log.Infof("Component %s stopped in %s", registered.name, time.Since(start).Round(time.Millisecond))
+	}
+	a.componentStopErrs += len(errs)
+	return errors.Join(errs...)
+}
+
+// RestartComponent stops and starts the named running component, e.g. to reconnect a stuck
+// client pool from an admin endpoint without restarting the process. Stop is bounded by the
+// shutdown timeout and Start gets the app context. A restart while another restart of the
+// same component is in progress fails, as does restarting a component that is not running.
+// If Stop fails the component is left running, if Start fails it stays stopped. Only
+// components implementing Restartable can be restarted; the GinService and TcpListener
+// adapters cannot.
+func (a *App) RestartComponent(name string) error {
+	a.componentsMu.Lock()
+	var registered *registeredComponent
+	for _, rc := range a.components {
+		if rc.name == name {
+			registered = rc
+			break
+		}
+	}
+	a.componentsMu.Unlock()
+
+	if registered == nil {
+		return fmt.Errorf("component %q is not registered", name)
+	}
+	if !registered.restarting.CompareAndSwap(false, true) {
+		return fmt.Errorf("component %q is already restarting", name)
+	}
+	defer registered.restarting.Store(false)
+
+	registered.mu.Lock()
+	defer registered.mu.Unlock()
+	if !registered.started {
+		return fmt.Errorf("component %q is not running", name)
+	}
+	if restartable, ok := registered.component.(Restartable); !ok || !restartable.CanRestart() {
+		return fmt.Errorf("component %q does not support restarting", name)
+	}
+
+	a.log.Infof("Restarting component %s", name)
+	ctx, cancel := context.WithTimeout(context.Background(), a.opt.ShutdownTimeout)
+	defer cancel()
+	if err := registered.component.Stop(ctx); err != nil {
+		return fmt.Errorf("stop component %q: %w", name, err)
+	}
+	registered.started = false
+
+	if err := registered.component.Start(a.ctx); err != nil {
+		a.log.Errorf("Failed to restart component %s, it stays stopped: %v", name, err)
+		return fmt.Errorf("start component %q: %w", name, err)
+	}
+	registered.started = true
+	a.log.Infof("Component %s restarted", name)
+	return nil
+}
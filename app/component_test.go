@@ -0,0 +1,272 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// fakeComponent counts Start and Stop calls
+type fakeComponent struct {
+	mu      sync.Mutex
+	starts  int
+	stops   int
+	restart bool
+}
+
+func (f *fakeComponent) Start(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.starts++
+	return nil
+}
+
+func (f *fakeComponent) Stop(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stops++
+	return nil
+}
+
+// restartableComponent is a fakeComponent implementing Restartable
+type restartableComponent struct {
+	fakeComponent
+}
+
+func (r *restartableComponent) CanRestart() bool { return true }
+
+// newTestApp returns an initialized app without running it
+func newTestApp(t *testing.T, opts ...Option) *App {
+	t.Helper()
+	a := NewApp("test", "test app")
+	a.Init(opts...)
+	return a
+}
+
+// markStarted marks the registered components as running, as runComponents would
+func markStarted(a *App) {
+	for _, registered := range a.components {
+		registered.started = true
+	}
+}
+
+func TestRestartComponent(t *testing.T) {
+	a := newTestApp(t)
+	plain := &fakeComponent{}
+	restartable := &restartableComponent{}
+	a.RegisterComponent("plain", plain)
+	a.RegisterComponent("restartable", restartable)
+
+	if err := a.RestartComponent("restartable"); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("Expected error restarting a stopped component, got %v", err)
+	}
+	markStarted(a)
+
+	if err := a.RestartComponent("restartable"); err != nil {
+		t.Fatalf("Failed to restart component: %v", err)
+	}
+	if restartable.starts != 1 || restartable.stops != 1 {
+		t.Errorf("Expected one Stop and one Start, got %d stops and %d starts", restartable.stops, restartable.starts)
+	}
+
+	if err := a.RestartComponent("plain"); err == nil || !strings.Contains(err.Error(), "does not support restarting") {
+		t.Errorf("Expected error restarting a component that is not Restartable, got %v", err)
+	}
+	if plain.stops != 0 {
+		t.Errorf("Expected a non restartable component to keep running, got %d stops", plain.stops)
+	}
+
+	if err := a.RestartComponent("missing"); err == nil {
+		t.Error("Expected error restarting an unregistered component")
+	}
+}
+
+func TestRegisterComponentDuringRestart(t *testing.T) {
+	a := newTestApp(t)
+	a.RegisterComponent("db", &restartableComponent{})
+	markStarted(a)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			a.RestartComponent("db")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			a.RegisterComponent("db", &restartableComponent{})
+		}
+	}()
+	wg.Wait()
+}
+
+// checkedComponent is a fakeComponent implementing Checker
+type checkedComponent struct {
+	fakeComponent
+	err error
+}
+
+func (c *checkedComponent) Check(ctx context.Context) error { return c.err }
+
+func TestCheckMode(t *testing.T) {
+	var out bytes.Buffer
+	a := newTestApp(t, WithCommands([]*cli.Command{{
+		Name: "serve",
+		Action: func(c *cli.Context) error {
+			t.Error("Expected the command action not to run in check mode")
+			return nil
+		},
+	}}))
+	a.app.Writer = &out
+	a.RegisterComponent("db", &checkedComponent{})
+
+	err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "--check", "serve"})
+	if !errors.Is(err, errCheckPassed) {
+		t.Fatalf("Expected check to pass, got %v", err)
+	}
+	if !strings.Contains(out.String(), "[OK]   component db") {
+		t.Errorf("Expected the component check to be reported, got %q", out.String())
+	}
+
+	failing := newTestApp(t)
+	failing.app.Writer = &out
+	failing.RegisterComponent("db", &checkedComponent{err: errors.New("connection refused")})
+	err = failing.app.RunContext(failing.ctx, []string{"test", "--config", "", "--check"})
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Expected the failing component check to fail check mode, got %v", err)
+	}
+}
+
+// orderedComponent appends its Start and Stop calls to a shared event list
+type orderedComponent struct {
+	name     string
+	events   *[]string
+	mu       *sync.Mutex
+	startErr error
+	onStart  func()
+}
+
+func (o *orderedComponent) Start(ctx context.Context) error {
+	o.record("start " + o.name)
+	if o.onStart != nil {
+		o.onStart()
+	}
+	return o.startErr
+}
+
+func (o *orderedComponent) Stop(ctx context.Context) error {
+	o.record("stop " + o.name)
+	return nil
+}
+
+func (o *orderedComponent) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	*o.events = append(*o.events, event)
+}
+
+// registerOrdered registers components named names sharing one event list
+func registerOrdered(a *App, names ...string) (map[string]*orderedComponent, func() []string) {
+	var mu sync.Mutex
+	var events []string
+	components := make(map[string]*orderedComponent)
+	for _, name := range names {
+		components[name] = &orderedComponent{name: name, events: &events, mu: &mu}
+		a.RegisterComponent(name, components[name])
+	}
+	return components, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, events...)
+	}
+}
+
+func TestRunComponentsOrder(t *testing.T) {
+	a := newTestApp(t)
+	_, events := registerOrdered(a, "db", "cache", "http")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := a.runComponents(ctx); err != nil {
+		t.Fatalf("Failed to run components: %v", err)
+	}
+
+	want := []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"}
+	if got := events(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRunComponentsStartFailure(t *testing.T) {
+	a := newTestApp(t)
+	components, events := registerOrdered(a, "db", "cache", "http")
+	components["cache"].startErr = errors.New("connection refused")
+
+	err := a.runComponents(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Expected the start error, got %v", err)
+	}
+
+	want := []string{"start db", "start cache", "stop db"}
+	if got := events(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected only started components to be stopped, got %v", got)
+	}
+}
+
+func TestRunComponentsStopOnSignal(t *testing.T) {
+	a := newTestApp(t)
+	components, events := registerOrdered(a, "db", "http")
+	// the signal handler is installed before the components start
+	components["http"].onStart = func() {
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.runComponents(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Failed to run components: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the components to stop on SIGTERM")
+	}
+
+	want := []string{"start db", "start http", "stop http", "stop db"}
+	if got := events(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRegisterComponentStopsReplacedComponent(t *testing.T) {
+	a := newTestApp(t)
+	old := &fakeComponent{}
+	a.RegisterComponent("db", old)
+	markStarted(a)
+
+	replacement := &fakeComponent{}
+	a.RegisterComponent("db", replacement)
+	if old.stops != 1 {
+		t.Errorf("Expected the running component to be stopped when replaced, got %d stops", old.stops)
+	}
+
+	if err := a.stopComponents(a.components); err != nil {
+		t.Fatalf("Failed to stop components: %v", err)
+	}
+	if replacement.stops != 0 || old.stops != 1 {
+		t.Errorf("Expected no stop of the replacement that never started, got %d and %d stops", replacement.stops, old.stops)
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// applyRunDeadline wraps the actions of all commands to run with a context bounded by the
+// run deadline. It returns a plain error rather than cli.Exit on timeout, since the cli
+// exits the process on an exit error before running the after hooks. Once the deadline
+// elapses the action is given the shutdown timeout to return, so the after hooks do not
+// run while it is still using the dependencies they close.
+func (a *App) applyRunDeadline(commands []*cli.Command) {
+	if a.opt.RunDeadline <= 0 {
+		return
+	}
+
+	for _, command := range commands {
+		a.applyRunDeadline(command.Subcommands)
+
+		if command.Action == nil {
+			continue
+		}
+
+		action := command.Action
+		command.Action = func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, a.opt.RunDeadline)
+			defer cancel()
+
+			// not restored afterwards, an action ignoring the deadline may still be using it
+			c.Context = ctx
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- action(c)
+			}()
+
+			var err error
+			select {
+			case err = <-errCh:
+			case <-ctx.Done():
+				err = a.awaitAction(errCh, ctx.Err())
+			}
+			if ctx.Err() == context.DeadlineExceeded {
+				if err == nil {
+					// the action noticed the deadline and returned cleanly, the run still failed
+					err = ctx.Err()
+				}
+				return fmt.Errorf("run deadline of %s exceeded: %w", a.opt.RunDeadline, err)
+			}
+			return err
+		}
+	}
+}
+
+// awaitAction waits up to the shutdown timeout for an action whose context is done and
+// returns its error, or fallback if the action is still running afterwards
+func (a *App) awaitAction(errCh <-chan error, fallback error) error {
+	timer := time.NewTimer(a.opt.ShutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			return fallback
+		}
+		return err
+	case <-timer.C:
+		a.log.Warnf("Command did not return within %s after its context was done, running after hooks anyway", a.opt.ShutdownTimeout)
+		return fallback
+	}
+}
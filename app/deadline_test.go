@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestRunDeadlineWaitsForAction(t *testing.T) {
+	var returned, afterSawRunning atomic.Bool
+	a := newTestApp(t,
+		WithRunDeadline(20*time.Millisecond),
+		AddAfter(func(c *cli.Context) error {
+			afterSawRunning.Store(!returned.Load())
+			return nil
+		}),
+		WithCommands([]*cli.Command{{
+			Name: "job",
+			Action: func(c *cli.Context) error {
+				<-c.Context.Done()
+				// cleanup still using dependencies the after hooks close
+				time.Sleep(50 * time.Millisecond)
+				returned.Store(true)
+				return nil
+			},
+		}}),
+	)
+
+	err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "job"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if afterSawRunning.Load() {
+		t.Error("Expected the after hooks to run once the action returned")
+	}
+}
+
+func TestRunDeadlineAbandonsStuckAction(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	a := newTestApp(t,
+		WithRunDeadline(10*time.Millisecond),
+		WithShutdownTimeout(20*time.Millisecond),
+		WithCommands([]*cli.Command{{
+			Name: "job",
+			Action: func(c *cli.Context) error {
+				<-release
+				return nil
+			},
+		}}),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- a.app.RunContext(a.ctx, []string{"test", "--config", "", "job"})
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the run to give up on an action ignoring its context")
+	}
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// disabledCommandsKey is the config key listing command names disabled at runtime
+const disabledCommandsKey = "commands.disabled"
+
+// disableCommands wraps the actions of all commands so disabled ones fail with an error
+// instead of running. Commands are disabled by the DisabledCommands predicate, known at Init and
+// marked in help (or hidden), or by the commands.disabled config list, checked when they run.
+func (a *App) disableCommands(commands []*cli.Command) {
+	for _, command := range commands {
+		a.disableCommands(command.Subcommands)
+
+		if command.Action == nil {
+			continue
+		}
+
+		if a.opt.DisabledCommands != nil && a.opt.DisabledCommands(command.Name) {
+			if a.opt.HideDisabledCommands {
+				command.Hidden = true
+			} else {
+				command.Usage = "[disabled] " + command.Usage
+			}
+		}
+
+		name := command.Name
+		action := command.Action
+		command.Action = func(c *cli.Context) error {
+			if a.isCommandDisabled(name) {
+				return cli.Exit(fmt.Sprintf("command %q is disabled", name), 1)
+			}
+			return action(c)
+		}
+	}
+}
+
+// isCommandDisabled reports whether a command is disabled by option or config
+func (a *App) isCommandDisabled(name string) bool {
+	if a.opt.DisabledCommands != nil && a.opt.DisabledCommands(name) {
+		return true
+	}
+	for _, disabled := range a.config.GetStringSlice(disabledCommandsKey) {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// disableTestCommands returns a serve and a reset-db command recording their runs in ran
+func disableTestCommands(ran map[string]bool) []*cli.Command {
+	action := func(c *cli.Context) error {
+		ran[c.Command.Name] = true
+		return nil
+	}
+	return []*cli.Command{
+		{Name: "serve", Usage: "serve requests", Action: action},
+		{Name: "reset-db", Usage: "drop all data", Action: action},
+	}
+}
+
+func TestDisabledCommands(t *testing.T) {
+	ran := make(map[string]bool)
+	newApp := func() *App {
+		a := newTestApp(t,
+			WithCommands(disableTestCommands(ran)),
+			WithDisabledCommands(func(name string) bool { return name == "reset-db" }),
+		)
+		a.app.ExitErrHandler = func(*cli.Context, error) {}
+		return a
+	}
+
+	a := newApp()
+	if usage := a.app.Command("reset-db").Usage; usage != "[disabled] drop all data" {
+		t.Errorf("Expected reset-db marked as disabled in help, got %q", usage)
+	}
+	err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "reset-db"})
+	if err == nil || !strings.Contains(err.Error(), `command "reset-db" is disabled`) || ran["reset-db"] {
+		t.Errorf("Expected reset-db to be refused, got %v", err)
+	}
+	a = newApp()
+	if err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "serve"}); err != nil || !ran["serve"] {
+		t.Errorf("Expected serve to run, got %v", err)
+	}
+
+	hidden := newTestApp(t,
+		WithCommands(disableTestCommands(ran)),
+		WithDisabledCommands(func(name string) bool { return name == "reset-db" }),
+		HideDisabledCommands(),
+	)
+	if command := hidden.app.Command("reset-db"); !command.Hidden || command.Usage != "drop all data" {
+		t.Errorf("Expected reset-db hidden with its usage unchanged, got hidden %v and %q", command.Hidden, command.Usage)
+	}
+}
+
+func TestDisabledCommandsFromConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("commands:\n  disabled: [reset-db]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	ran := make(map[string]bool)
+	a := newTestApp(t, WithCommands(disableTestCommands(ran)))
+	a.app.ExitErrHandler = func(*cli.Context, error) {}
+
+	err := a.app.RunContext(a.ctx, []string{"test", "--config", configFile, "reset-db"})
+	if err == nil || !strings.Contains(err.Error(), "is disabled") || ran["reset-db"] {
+		t.Errorf("Expected reset-db disabled by config, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli/v2"
+)
+
+// flagsCommandName is the name of the hidden command printing the flag reference
+const flagsCommandName = "flags"
+
+// commonEnvBindings are the env vars of built-in config keys that don't follow the prefix pattern
+var commonEnvBindings = map[string]string{
+	"log.level":  "LOG_LEVEL",
+	"log.format": "LOG_FORMAT",
+	"env":        "ENV",
+}
+
+// FlagDoc describes a CLI flag for generated documentation
+type FlagDoc struct {
+	// Command is the space separated command path of a command flag, empty for global flags
+	Command string
+	Name    string
+	Aliases []string
+	Default string
+	// EnvVars are the env vars setting the flag or the config key it is bound to
+	EnvVars []string
+	Usage   string
+}
+
+// FlagDocs returns the global flags, built-in and custom, followed by the flags of all visible
+// commands. The help and version flags are left out.
+func (a *App) FlagDocs() []FlagDoc {
+	if a.app == nil {
+		panic("please call Init() first")
+	}
+
+	docs := a.flagDocs("", a.app.Flags, true)
+	var walk func(path string, commands []*cli.Command)
+	walk = func(path string, commands []*cli.Command) {
+		for _, command := range commands {
+			if command.Hidden {
+				continue
+			}
+			commandPath := strings.TrimSpace(path + " " + command.Name)
+			docs = append(docs, a.flagDocs(commandPath, command.Flags, false)...)
+			walk(commandPath, command.Subcommands)
+		}
+	}
+	walk("", a.app.Commands)
+	return docs
+}
+
+// flagDocs describes flags of the command at path, global flags may be bound to config keys
+func (a *App) flagDocs(path string, flags []cli.Flag, global bool) []FlagDoc {
+	docs := make([]FlagDoc, 0, len(flags))
+	for _, flag := range flags {
+		if flag == cli.HelpFlag || flag == cli.VersionFlag {
+			continue
+		}
+		names := flag.Names()
+		doc := FlagDoc{
+			Command: path,
+			Name:    names[0],
+			Aliases: names[1:],
+		}
+		if docFlag, ok := flag.(cli.DocGenerationFlag); ok {
+			doc.Usage = docFlag.GetUsage()
+			if docFlag.TakesValue() {
+				doc.Default = docFlag.GetDefaultText()
+				// string defaults are quoted for the help output
+				if unquoted, err := strconv.Unquote(doc.Default); err == nil {
+					doc.Default = unquoted
+				}
+			}
+			doc.EnvVars = append(doc.EnvVars, docFlag.GetEnvVars()...)
+		}
+		if global {
+			if envVar := a.configEnvVar(flag); envVar != "" {
+				doc.EnvVars = append(doc.EnvVars, envVar)
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// configEnvVar returns the env var of the config key a global flag is bound to, if any:
+// the built-in keys have fixed env vars, duration and size flags are bound under their name
+// and resolved by automatic env lookup (see bindFlagValues)
+func (a *App) configEnvVar(flag cli.Flag) string {
+	name := flag.Names()[0]
+	if envVar, ok := commonEnvBindings[name]; ok {
+		return envVar
+	}
+	if envVar, ok := a.opt.EnvBindings[name]; ok {
+		return envVar
+	}
+
+	switch f := flag.(type) {
+	case *cli.DurationFlag:
+	case *cli.GenericFlag:
+		if _, ok := f.Value.(*SizeValue); !ok {
+			return ""
+		}
+	default:
+		return ""
+	}
+	if a.opt.StrictEnv {
+		return ""
+	}
+	envVar := strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+	if a.opt.EnvPrefix != "" {
+		envVar = strings.ToUpper(a.opt.EnvPrefix) + "_" + envVar
+	}
+	return envVar
+}
+
+// flagsCommand returns the hidden command printing the flag reference
+func (a *App) flagsCommand() *cli.Command {
+	return &cli.Command{
+		Name:   flagsCommandName,
+		Usage:  "print a reference of all flags",
+		Hidden: true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Value: "table",
+				Usage: "output format (table, markdown)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			docs := a.FlagDocs()
+			switch format := c.String("format"); format {
+			case "table":
+				return writeFlagTable(c.App.Writer, docs)
+			case "markdown":
+				return writeFlagMarkdown(c.App.Writer, docs)
+			default:
+				return cli.Exit(fmt.Sprintf("unsupported format %q, use table or markdown", format), 1)
+			}
+		},
+	}
+}
+
+// flagNames returns the flag with its aliases as written on the command line
+func (d FlagDoc) flagNames() string {
+	names := make([]string, 0, 1+len(d.Aliases))
+	for _, name := range append([]string{d.Name}, d.Aliases...) {
+		if len(name) == 1 {
+			names = append(names, "-"+name)
+		} else {
+			names = append(names, "--"+name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeFlagTable writes docs as an aligned plain text table
+func writeFlagTable(w io.Writer, docs []FlagDoc) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMMAND\tFLAG\tDEFAULT\tENV\tUSAGE")
+	for _, doc := range docs {
+		command := doc.Command
+		if command == "" {
+			command = "(global)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			command, doc.flagNames(), doc.Default, strings.Join(doc.EnvVars, ", "), doc.Usage)
+	}
+	return tw.Flush()
+}
+
+// writeFlagMarkdown writes docs as a markdown table
+func writeFlagMarkdown(w io.Writer, docs []FlagDoc) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", " ").Replace
+	code := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + s + "`"
+	}
+
+	fmt.Fprintln(w, "| Command | Flag | Default | Env | Usage |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	for _, doc := range docs {
+		envVars := make([]string, 0, len(doc.EnvVars))
+		for _, envVar := range doc.EnvVars {
+			envVars = append(envVars, code(envVar))
+		}
+		_, err := fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+			escape(doc.Command), code(doc.flagNames()), escape(code(doc.Default)),
+			strings.Join(envVars, ", "), escape(doc.Usage))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
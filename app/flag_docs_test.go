@@ -0,0 +1,32 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestFlagsCommandSkipsInit(t *testing.T) {
+	var out bytes.Buffer
+	a := newTestApp(t, WithFlags([]cli.Flag{DurationFlag("timeout", "request timeout", 0)}))
+	a.app.Writer = &out
+
+	if err := a.app.RunContext(a.ctx, []string{"test", "flags", "--format", "markdown"}); err != nil {
+		t.Fatalf("Failed to run flags: %v", err)
+	}
+	if !strings.Contains(out.String(), "timeout") || !strings.Contains(out.String(), "config") {
+		t.Errorf("Expected the flag reference, got %q", out.String())
+	}
+	if _, phases := a.Initialized(); len(phases) != 0 {
+		t.Errorf("Expected flags to skip initialization, got phases %v", phases)
+	}
+}
+
+func TestFlagsCommandCollision(t *testing.T) {
+	a := newTestApp(t, WithCommands([]*cli.Command{{Name: "flags"}}))
+	if err := a.checkBuiltinCommandNames(); err == nil || !strings.Contains(err.Error(), "built-in flags command") {
+		t.Errorf("Expected a collision with the flags command, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// DurationFlag returns a flag parsed as a time.Duration (e.g. 30s, 5m). Malformed values such
+// as 30x are rejected before the command runs. The parsed value is also set in the config
+// manager under the flag name, unless the config already provides it and the flag is not set.
+func DurationFlag(name, usage string, value time.Duration) cli.Flag {
+	return &cli.DurationFlag{
+		Name:  name,
+		Usage: usage,
+		Value: value,
+	}
+}
+
+// SizeFlag returns a flag parsed as a byte size (e.g. 512, 64KB, 10MB, 1GiB), validated before
+// the command runs and bound into the config manager as a number of bytes like DurationFlag.
+// It panics if value is not a valid size.
+func SizeFlag(name, usage, value string) cli.Flag {
+	size := &SizeValue{}
+	if err := size.Set(value); err != nil {
+		panic(fmt.Sprintf("invalid default size for flag %s: %v", name, err))
+	}
+	return &cli.GenericFlag{
+		Name:        name,
+		Usage:       usage,
+		Value:       size,
+		DefaultText: value,
+	}
+}
+
+// SizeValue is a byte size flag value, units are B, K/KB/KiB, M/MB/MiB, G/GB/GiB and T/TB/TiB,
+// all powers of 1024 and case-insensitive
+type SizeValue struct {
+	bytes int64
+}
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+// Set parses a size such as 10MB
+func (s *SizeValue) Set(value string) error {
+	value = strings.TrimSpace(value)
+	i := 0
+	for i < len(value) && (value[i] >= '0' && value[i] <= '9' || value[i] == '.') {
+		i++
+	}
+
+	number, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil || number < 0 {
+		return fmt.Errorf("invalid size %q", value)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(value[i:]))]
+	if !ok {
+		return fmt.Errorf("invalid size unit in %q", value)
+	}
+
+	// float64(math.MaxInt64) rounds up to 2^63, which no longer fits an int64
+	size := number * float64(unit)
+	if size >= math.MaxInt64 {
+		return fmt.Errorf("size %q overflows int64", value)
+	}
+	s.bytes = int64(size)
+	return nil
+}
+
+// String returns the size in bytes
+func (s *SizeValue) String() string {
+	return strconv.FormatInt(s.bytes, 10)
+}
+
+// Bytes returns the size in bytes
+func (s *SizeValue) Bytes() int64 {
+	return s.bytes
+}
+
+// bindCommandFlagValues binds the duration and size flags of commands once they are parsed,
+// which happens after the app's Before, so the flags of the running command are bound too
+func (a *App) bindCommandFlagValues(commands []*cli.Command) {
+	for _, command := range commands {
+		a.bindCommandFlagValues(command.Subcommands)
+
+		before := command.Before
+		command.Before = func(c *cli.Context) error {
+			a.bindFlagValues(c)
+			if before != nil {
+				return before(c)
+			}
+			return nil
+		}
+	}
+}
+
+// bindFlagValues sets parsed duration and size flags of the command and its parents in the
+// config manager under the flag name. Parents are bound first, so a flag of the running
+// command wins over a parent flag of the same name. An explicitly set flag always wins,
+// a default only fills in keys the config does not provide.
+func (a *App) bindFlagValues(c *cli.Context) {
+	lineage := c.Lineage()
+	for i := len(lineage) - 1; i >= 0; i-- {
+		ctx := lineage[i]
+		if ctx.Command == nil {
+			continue
+		}
+		for _, flag := range ctx.Command.Flags {
+			var name, value string
+			switch f := flag.(type) {
+			case *cli.DurationFlag:
+				name, value = f.Name, ctx.Duration(f.Name).String()
+			case *cli.GenericFlag:
+				size, ok := ctx.Generic(f.Name).(*SizeValue)
+				if !ok {
+					continue
+				}
+				name, value = f.Name, size.String()
+			default:
+				continue
+			}
+
+			if ctx.IsSet(name) || !a.config.Viper().IsSet(name) {
+				a.config.Set(name, value)
+			}
+		}
+	}
+}
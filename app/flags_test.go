@@ -0,0 +1,72 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestCommandFlagValuesAreBound(t *testing.T) {
+	var timeout, limit, retry string
+	var a *App
+	a = newTestApp(t,
+		WithFlags([]cli.Flag{DurationFlag("retry", "retry interval", 0)}),
+		WithCommands([]*cli.Command{{
+			Name:  "job",
+			Flags: []cli.Flag{DurationFlag("timeout", "job timeout", 0)},
+			Subcommands: []*cli.Command{{
+				Name:  "run",
+				Flags: []cli.Flag{SizeFlag("limit", "body limit", "1KB")},
+				Action: func(c *cli.Context) error {
+					timeout = a.Config().GetString("timeout")
+					limit = a.Config().GetString("limit")
+					retry = a.Config().GetString("retry")
+					return nil
+				},
+			}},
+		}}),
+	)
+
+	args := []string{"test", "--config", "", "--retry", "5s", "job", "--timeout", "45s", "run", "--limit", "10MB"}
+	if err := a.app.RunContext(a.ctx, args); err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	if timeout != "45s" {
+		t.Errorf("Expected the parent command flag to be bound, got %q", timeout)
+	}
+	if limit != "10485760" {
+		t.Errorf("Expected the running command flag to be bound, got %q", limit)
+	}
+	if retry != "5s" {
+		t.Errorf("Expected the app flag to be bound, got %q", retry)
+	}
+}
+
+func TestSizeValueSet(t *testing.T) {
+	tests := []struct {
+		value   string
+		bytes   int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"1.5KiB", 1536, false},
+		{"2 MB", 2 << 20, false},
+		{"8388607T", 8388607 << 40, false},
+		{"8388608T", 0, true},
+		{"9223372036854775807", 0, true},
+		{"100000000000000000000", 0, true},
+		{"-1K", 0, true},
+		{"10X", 0, true},
+	}
+	for _, tt := range tests {
+		var size SizeValue
+		err := size.Set(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q): expected error %v, got %v", tt.value, tt.wantErr, err)
+			continue
+		}
+		if !tt.wantErr && size.Bytes() != tt.bytes {
+			t.Errorf("Set(%q): expected %d bytes, got %d", tt.value, tt.bytes, size.Bytes())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// healthCheckCommandName is the name of the built-in health check command
+const healthCheckCommandName = "healthcheck"
+
+// defaultHealthCheckTimeout bounds the readiness request of the health check command
+const defaultHealthCheckTimeout = 3 * time.Second
+
+// healthCheckURL returns the readiness URL for addr, either a base URL or a host:port
+// with the host defaulting to localhost
+func healthCheckURL(addr string) string {
+	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
+		return strings.TrimSuffix(addr, "/") + "/readyz"
+	}
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	return "http://" + addr + "/readyz"
+}
+
+// healthCheckCommand returns a command requesting the readiness endpoint at addr and
+// exiting 0 on a 2xx response and 1 otherwise
+func (a *App) healthCheckCommand() *cli.Command {
+	return &cli.Command{
+		Name:  healthCheckCommandName,
+		Usage: "check the readiness endpoint of a running instance, e.g. for a Docker HEALTHCHECK",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: defaultHealthCheckTimeout,
+				Usage: "request timeout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithTimeout(c.Context, c.Duration("timeout"))
+			defer cancel()
+
+			url := healthCheckURL(a.opt.HealthCheckAddr)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("unhealthy: %v", err), 1)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return cli.Exit(fmt.Sprintf("unhealthy: %v", err), 1)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return cli.Exit(fmt.Sprintf("unhealthy: %s returned %s", url, resp.Status), 1)
+			}
+			fmt.Fprintf(c.App.Writer, "healthy: %s returned %s\n", url, resp.Status)
+			return nil
+		},
+	}
+}
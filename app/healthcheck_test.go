@@ -0,0 +1,61 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestHealthCheckURL(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{":8080", "http://127.0.0.1:8080/readyz"},
+		{"api:8080", "http://api:8080/readyz"},
+		{"https://api.internal/", "https://api.internal/readyz"},
+	}
+	for _, tt := range tests {
+		if got := healthCheckURL(tt.addr); got != tt.want {
+			t.Errorf("healthCheckURL(%q): expected %q, got %q", tt.addr, tt.want, got)
+		}
+	}
+}
+
+func TestHealthCheckCommand(t *testing.T) {
+	ready := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" || !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	run := func() (string, error) {
+		var out bytes.Buffer
+		a := newTestApp(t, WithHealthCheckCommand(server.URL))
+		a.app.Writer = &out
+		a.app.ExitErrHandler = func(*cli.Context, error) {}
+		err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "healthcheck"})
+		return out.String(), err
+	}
+
+	out, err := run()
+	if err != nil {
+		t.Errorf("Expected a ready instance to be healthy, got %v", err)
+	}
+	if !strings.Contains(out, "healthy: "+server.URL+"/readyz returned 200 OK") {
+		t.Errorf("Unexpected output %q", out)
+	}
+
+	ready = false
+	if _, err := run(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Expected an unready instance to be unhealthy, got %v", err)
+	}
+}
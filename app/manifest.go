@@ -0,0 +1,152 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/letusgogo/quick/config"
+)
+
+// StartupManifest describes a running process for fleet management
+type StartupManifest struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	GoVersion string    `json:"go_version"`
+	// Build info from the binary, empty when not built from a module
+	Module      string `json:"module,omitempty"`
+	VCSRevision string `json:"vcs_revision,omitempty"`
+	VCSTime     string `json:"vcs_time,omitempty"`
+	// ConfigHash is a sha256 of the resolved config, it detects drift without exposing values.
+	// Values of sensitive keys are left out, so the hash cannot be used to guess a secret and
+	// rotating one does not count as drift.
+	ConfigHash string `json:"config_hash"`
+	// Ports are the configured values of keys named port (e.g. server.port)
+	Ports map[string]string `json:"ports"`
+	// Features are the optional app features enabled by options, followed by the registered
+	// workers, components and providers, e.g. "worker:cleanup", "component:http", "provider:db"
+	Features []string `json:"features"`
+}
+
+// Manifest builds the startup manifest from the current app state
+func (a *App) Manifest() StartupManifest {
+	manifest := StartupManifest{
+		Name:      a.Name,
+		Version:   a.Version,
+		PID:       os.Getpid(),
+		StartedAt: a.startedAt,
+		GoVersion: runtime.Version(),
+		Ports:     make(map[string]string),
+		Features:  a.features(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		manifest.Module = info.Main.Path
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				manifest.VCSRevision = setting.Value
+			case "vcs.time":
+				manifest.VCSTime = setting.Value
+			}
+		}
+	}
+
+	flat := a.config.Flatten()
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	for _, key := range keys {
+		value := flat[key]
+		if config.IsSensitiveKey(key) {
+			value = config.RedactedValue
+		}
+		hash.Write([]byte(key + "=" + value + "\n"))
+		if key == "port" || strings.HasSuffix(key, ".port") {
+			manifest.Ports[key] = flat[key]
+		}
+	}
+	manifest.ConfigHash = hex.EncodeToString(hash.Sum(nil))
+
+	return manifest
+}
+
+// features lists the optional features enabled by options
+func (a *App) features() []string {
+	features := []string{}
+	if a.opt.ValidateCommand {
+		features = append(features, "validate_command")
+	}
+	if a.opt.StrictEnv {
+		features = append(features, "strict_env")
+	}
+	if a.opt.DisabledCommands != nil {
+		features = append(features, "disabled_commands")
+	}
+	if a.opt.StartupManifest != "" {
+		features = append(features, "startup_manifest")
+	}
+	if a.opt.RunDeadline > 0 {
+		features = append(features, "run_deadline")
+	}
+	if a.opt.HealthCheckAddr != "" {
+		features = append(features, "health_check_command")
+	}
+
+	a.componentsMu.Lock()
+	for _, registered := range a.components {
+		kind := "component"
+		registered.mu.Lock()
+		if _, ok := registered.component.(*Worker); ok {
+			kind = "worker"
+		}
+		registered.mu.Unlock()
+		features = append(features, kind+":"+registered.name)
+	}
+	a.componentsMu.Unlock()
+
+	a.providersMu.Lock()
+	providers := make([]string, 0, len(a.providers))
+	for name := range a.providers {
+		providers = append(providers, "provider:"+name)
+	}
+	a.providersMu.Unlock()
+	sort.Strings(providers)
+	return append(features, providers...)
+}
+
+// writeManifest writes the startup manifest to path, replacing it atomically
+func (a *App) writeManifest(path string) error {
+	data, err := json.MarshalIndent(a.Manifest(), "", "    ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".manifest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestManifestConfigHashSkipsSecrets(t *testing.T) {
+	a := newTestApp(t)
+	a.config.Set("server.port", "8080")
+	a.config.Set("database.password", "hunter2")
+	hash := a.Manifest().ConfigHash
+
+	a.config.Set("database.password", "rotated")
+	if got := a.Manifest().ConfigHash; got != hash {
+		t.Errorf("Expected a secret not to change the config hash, got %s and %s", hash, got)
+	}
+
+	a.config.Set("server.port", "9090")
+	if got := a.Manifest().ConfigHash; got == hash {
+		t.Error("Expected a changed value to change the config hash")
+	}
+}
+
+func TestManifestFeatures(t *testing.T) {
+	a := newTestApp(t, WithRunDeadline(time.Minute))
+	a.RegisterComponent("http", &fakeComponent{})
+	a.RegisterComponent("cleanup", PeriodicWorker(time.Hour, func(ctx context.Context) error { return nil }))
+	a.Provide("db", func() (interface{}, error) { return nil, nil })
+	a.Provide("cache", func() (interface{}, error) { return nil, nil })
+
+	expected := []string{"run_deadline", "component:http", "worker:cleanup", "provider:cache", "provider:db"}
+	if got := a.Manifest().Features; !slices.Equal(got, expected) {
+		t.Errorf("Expected features %v, got %v", expected, got)
+	}
+}
+
+func TestWorkerComponent(t *testing.T) {
+	calls := make(chan struct{}, 1)
+	worker := PeriodicWorker(time.Millisecond, func(ctx context.Context) error {
+		select {
+		case calls <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	if err := worker.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start worker: %v", err)
+	}
+	if err := worker.Start(context.Background()); err == nil {
+		t.Error("Expected an error starting a running worker")
+	}
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the worker to run")
+	}
+
+	if err := worker.Stop(context.Background()); err != nil {
+		t.Fatalf("Failed to stop worker: %v", err)
+	}
+	if err := worker.Start(context.Background()); err != nil {
+		t.Errorf("Expected a stopped worker to start again, got %v", err)
+	}
+	worker.Stop(context.Background())
+}
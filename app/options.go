@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"time"
 
+	"github.com/letusgogo/quick/config"
 	"github.com/urfave/cli/v2"
 )
 
@@ -14,12 +16,55 @@ type Options struct {
 	// Environment variable prefix (e.g., "APP" for APP_SERVER_PORT)
 	EnvPrefix string
 
+	// Only honor explicitly bound environment variables
+	StrictEnv bool
+
 	// Command line flags
 	Flags []cli.Flag
 
 	// Sub commands
 	Commands []*cli.Command
 
+	// Sub commands grouped by help category
+	CommandGroups map[string][]*cli.Command
+
+	// Sub commands hidden from help output
+	HiddenCommands []*cli.Command
+
+	// Predicate selecting commands that must not run
+	DisabledCommands func(name string) bool
+
+	// Hide disabled commands from help instead of marking them
+	HideDisabledCommands bool
+
+	// Path the startup manifest is written to after initialization
+	StartupManifest string
+
+	// Config keys declared by the app, other keys in the config file are reported at startup
+	KnownConfigKeys []string
+
+	// Deadline of a command run, zero means no deadline
+	RunDeadline time.Duration
+
+	// Defaults of the log.level and log.format flags
+	LogLevel  string
+	LogFormat string
+
+	// Valid values of the env flag, the first one is its default
+	Environments []string
+
+	// Value completers of flags by flag name, see WithCompletions
+	Completions map[string]func() []string
+
+	// Time allowed for stopping all registered components
+	ShutdownTimeout time.Duration
+
+	// Config keys that must be set, checked at startup and by the validate command
+	RequiredKeys []string
+
+	// Custom config validations run at startup and by the validate command
+	ConfigValidators []func(m *config.Manager) error
+
 	// Before and After functions
 	Before []func(*cli.Context) error
 	After  []func(*cli.Context) error
@@ -29,19 +74,32 @@ type Options struct {
 
 	// Environment variable bindings for configuration
 	EnvBindings map[string]string
+
+	// Enable the built-in validate command
+	ValidateCommand bool
+
+	// Struct used by the validate command to check the config, may be nil
+	ValidateSchema interface{}
+
+	// Address of the running instance checked by the healthcheck command
+	HealthCheckAddr string
 }
 
 // NewOptions creates a new Options instance with default values
 func NewOptions() *Options {
 	return &Options{
-		ConfigFile:  "",
-		EnvPrefix:   "",
-		Flags:       nil,
-		Commands:    nil,
-		Before:      nil,
-		After:       nil,
-		Context:     context.Background(),
-		EnvBindings: make(map[string]string),
+		ConfigFile:      "",
+		EnvPrefix:       "",
+		Flags:           nil,
+		Commands:        nil,
+		Before:          nil,
+		After:           nil,
+		Context:         context.Background(),
+		EnvBindings:     make(map[string]string),
+		LogLevel:        "info",
+		LogFormat:       "text",
+		Environments:    []string{"dev", "test", "prod"},
+		ShutdownTimeout: defaultShutdownTimeout,
 	}
 }
 
@@ -63,6 +121,14 @@ func WithEnvPrefix(prefix string) Option {
 	}
 }
 
+// WithStrictEnv only reads explicitly bound environment variables (WithEnvBindings, built-in
+// log.level/log.format/env bindings) instead of any variable matching the prefix pattern
+func WithStrictEnv() Option {
+	return func(o *Options) {
+		o.StrictEnv = true
+	}
+}
+
 // WithCommands sets the CLI commands
 func WithCommands(commands []*cli.Command) Option {
 	return func(o *Options) {
@@ -70,6 +136,122 @@ func WithCommands(commands []*cli.Command) Option {
 	}
 }
 
+// WithCommandGroups adds commands grouped by category, so help output lists them under
+// a heading per category. It can be combined with WithCommands.
+func WithCommandGroups(groups map[string][]*cli.Command) Option {
+	return func(o *Options) {
+		if o.CommandGroups == nil {
+			o.CommandGroups = make(map[string][]*cli.Command)
+		}
+		for category, commands := range groups {
+			o.CommandGroups[category] = append(o.CommandGroups[category], commands...)
+		}
+	}
+}
+
+// WithHiddenCommands adds internal commands that can be run but are not shown in help output
+func WithHiddenCommands(commands []*cli.Command) Option {
+	return func(o *Options) {
+		o.HiddenCommands = append(o.HiddenCommands, commands...)
+	}
+}
+
+// WithDisabledCommands disables the commands for which disabled returns true: running them
+// fails with a "command disabled" error, and help marks them as [disabled].
+// Commands listed in the commands.disabled config key (or env) are disabled as well,
+// which lets ops lock down a shared binary per environment without recompiling.
+func WithDisabledCommands(disabled func(name string) bool) Option {
+	return func(o *Options) {
+		o.DisabledCommands = disabled
+	}
+}
+
+// HideDisabledCommands hides commands disabled by WithDisabledCommands from help output
+func HideDisabledCommands() Option {
+	return func(o *Options) {
+		o.HideDisabledCommands = true
+	}
+}
+
+// WithStartupManifest writes a JSON manifest (name, version, build info, config hash,
+// configured ports, enabled features and PID) to path once initialization completes
+func WithStartupManifest(path string) Option {
+	return func(o *Options) {
+		o.StartupManifest = path
+	}
+}
+
+// WithConfigSchema declares the config keys the app reads from the fields of schema under prefix
+// (an empty prefix for the whole config). Once a schema is declared, keys in the config file
+// not covered by any schema or built-in key are logged as warnings at startup.
+func WithConfigSchema(prefix string, schema interface{}) Option {
+	return func(o *Options) {
+		o.KnownConfigKeys = append(o.KnownConfigKeys, config.StructKeys(prefix, schema)...)
+	}
+}
+
+// WithLogDefaults sets the defaults of the log.level and log.format flags, empty values keep
+// the built-in defaults (info, text)
+func WithLogDefaults(level, format string) Option {
+	return func(o *Options) {
+		if level != "" {
+			o.LogLevel = level
+		}
+		if format != "" {
+			o.LogFormat = format
+		}
+	}
+}
+
+// WithEnvironments sets the valid values of the env flag and config key, the first one is
+// the default. Other values fail at startup.
+func WithEnvironments(envs ...string) Option {
+	return func(o *Options) {
+		if len(envs) > 0 {
+			o.Environments = envs
+		}
+	}
+}
+
+// WithRequiredKeys makes the app refuse to start when any of keys is unset or empty in the
+// config file and env vars, listing all missing keys. The validate command reports them too.
+func WithRequiredKeys(keys ...string) Option {
+	return func(o *Options) {
+		o.RequiredKeys = append(o.RequiredKeys, keys...)
+	}
+}
+
+// AddConfigValidator adds a custom config validation, e.g. a cross-field rule. All validators
+// run once the config is loaded and startup fails with all their errors.
+func AddConfigValidator(fn func(m *config.Manager) error) Option {
+	return func(o *Options) {
+		o.ConfigValidators = append(o.ConfigValidators, fn)
+	}
+}
+
+// WithCompletions sets shell completers for flag values by flag name, e.g. "region" completing
+// `--region` with the known regions. They replace the built-in completers of env, log.level and
+// log.format. Flags taking a value without completer complete file paths.
+func WithCompletions(completers map[string]func() []string) Option {
+	return func(o *Options) {
+		if o.Completions == nil {
+			o.Completions = make(map[string]func() []string)
+		}
+		for name, completer := range completers {
+			o.Completions[name] = completer
+		}
+	}
+}
+
+// WithShutdownTimeout sets the time allowed for stopping all registered components, default 30s
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.ShutdownTimeout = d
+		}
+	}
+}
+
 // WithFlags sets the CLI flags
 func WithFlags(flags []cli.Flag) Option {
 	return func(o *Options) {
@@ -114,3 +296,36 @@ func AddEnvBinding(key, envVar string) Option {
 		o.EnvBindings[key] = envVar
 	}
 }
+
+// WithValidateCommand enables the built-in `validate` command, which loads the config file,
+// unmarshals it into a new instance of schema, runs its `validate` tags and exits.
+// schema may be nil to only check that the config file loads.
+func WithValidateCommand(schema interface{}) Option {
+	return func(o *Options) {
+		o.ValidateCommand = true
+		o.ValidateSchema = schema
+	}
+}
+
+// WithHealthCheckCommand enables the built-in `healthcheck` command, which requests /readyz
+// at addr (e.g. ":8080" or "http://127.0.0.1:8080") and exits 0 on a 2xx response and 1
+// otherwise, so a Docker HEALTHCHECK needs no curl in the image.
+func WithHealthCheckCommand(addr string) Option {
+	return func(o *Options) {
+		o.HealthCheckAddr = addr
+	}
+}
+
+// WithRunDeadline bounds every command run by d, for batch jobs that must not run forever.
+// The command's context is cancelled once d elapses and the run fails with an error wrapping
+// context.DeadlineExceeded, so the process exits non-zero after the after hooks ran.
+// Actions get the shutdown timeout to return after the deadline, so the after hooks never run
+// under them; actions ignoring their context longer than that are abandoned with a warning.
+// The deadline context derives from the app context, so a cancelled parent from WithContext
+// (e.g. on a signal) also stops the command; WaitForSignal callbacks are independent and
+// must stop their own work.
+func WithRunDeadline(d time.Duration) Option {
+	return func(o *Options) {
+		o.RunDeadline = d
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+// Initialization phases reported by Initialized, in the order they complete
+const (
+	PhaseConfig = "config"
+	PhaseLogger = "logger"
+	PhaseHooks  = "hooks"
+)
+
+// initPhases lists all phases that must complete before the app is initialized
+var initPhases = []string{PhaseConfig, PhaseLogger, PhaseHooks}
+
+// markPhase records a completed initialization phase
+func (a *App) markPhase(phase string) {
+	a.phasesMu.Lock()
+	defer a.phasesMu.Unlock()
+	a.phases = append(a.phases, phase)
+}
+
+// Initialized reports whether all initialization phases (config loaded, logger initialized,
+// before hooks run) completed, and which ones did. It can back a readiness probe that is
+// more honest than an open socket.
+func (a *App) Initialized() (bool, []string) {
+	a.phasesMu.Lock()
+	defer a.phasesMu.Unlock()
+
+	completed := make([]string, len(a.phases))
+	copy(completed, a.phases)
+	return len(completed) == len(initPhases), completed
+}
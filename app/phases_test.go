@@ -0,0 +1,35 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestInitialized(t *testing.T) {
+	serve := []*cli.Command{{Name: "serve", Action: func(c *cli.Context) error { return nil }}}
+
+	a := newTestApp(t, WithCommands(serve))
+	if ready, phases := a.Initialized(); ready || len(phases) != 0 {
+		t.Errorf("Expected no phases before running, got %v", phases)
+	}
+	if err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "serve"}); err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	if ready, phases := a.Initialized(); !ready || !slices.Equal(phases, initPhases) {
+		t.Errorf("Expected all phases after running, got %v", phases)
+	}
+
+	failing := newTestApp(t, WithCommands(serve), AddBefore(func(c *cli.Context) error {
+		return errors.New("hook failed")
+	}))
+	if err := failing.app.RunContext(failing.ctx, []string{"test", "--config", "", "serve"}); err == nil {
+		t.Fatal("Expected the failing hook to fail the run")
+	}
+	want := []string{PhaseConfig, PhaseLogger}
+	if ready, phases := failing.Initialized(); ready || !slices.Equal(phases, want) {
+		t.Errorf("Expected phases %v without the hooks, got %v", want, phases)
+	}
+}
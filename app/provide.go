@@ -0,0 +1,86 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// provider lazily constructs a shared dependency once
+type provider struct {
+	name        string
+	constructor func() (interface{}, error)
+	once        sync.Once
+	instance    interface{}
+	err         error
+}
+
+// Provide registers a shared dependency under name. The constructor runs once, on the first
+// Resolve, and every later Resolve returns the same instance (or the same error).
+// Instances implementing io.Closer are closed in reverse construction order when the app exits.
+// Providing a name again replaces the registration; an instance already constructed by the
+// replaced provider is still closed on exit.
+func (a *App) Provide(name string, constructor func() (interface{}, error)) {
+	a.providersMu.Lock()
+	defer a.providersMu.Unlock()
+
+	if a.providers == nil {
+		a.providers = make(map[string]*provider)
+	}
+	a.providers[name] = &provider{name: name, constructor: constructor}
+}
+
+// Resolve returns the dependency registered under name as T, constructing it on first use
+func Resolve[T any](a *App, name string) (T, error) {
+	var zero T
+
+	a.providersMu.Lock()
+	p, ok := a.providers[name]
+	a.providersMu.Unlock()
+	if !ok {
+		return zero, fmt.Errorf("dependency %q not provided", name)
+	}
+
+	p.once.Do(func() {
+		p.instance, p.err = p.constructor()
+		if p.err == nil {
+			a.providersMu.Lock()
+			a.constructed = append(a.constructed, p)
+			a.providersMu.Unlock()
+		}
+	})
+	if p.err != nil {
+		return zero, fmt.Errorf("construct dependency %q: %w", name, p.err)
+	}
+
+	instance, ok := p.instance.(T)
+	if !ok {
+		return zero, fmt.Errorf("dependency %q is %T, not %T", name, p.instance, zero)
+	}
+	return instance, nil
+}
+
+// closeProviders closes constructed dependencies implementing io.Closer in reverse construction order.
+// It returns the number of closed dependencies and the close errors, which are also logged.
+func (a *App) closeProviders() (int, []error) {
+	a.providersMu.Lock()
+	constructed := a.constructed
+	a.constructed = nil
+	a.providersMu.Unlock()
+
+	closed := 0
+	var errs []error
+	for i := len(constructed) - 1; i >= 0; i-- {
+		p := constructed[i]
+		closer, ok := p.instance.(io.Closer)
+		if !ok {
+			continue
+		}
+		closed++
+		if err := closer.Close(); err != nil {
+			a.log.Errorf("Failed to close dependency %s: %v", p.name, err)
+			errs = append(errs, fmt.Errorf("close dependency %q: %w", p.name, err))
+		}
+	}
+	return closed, errs
+}
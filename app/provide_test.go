@@ -0,0 +1,69 @@
+package app
+
+import "testing"
+
+// closeRecorder records its name when closed
+type closeRecorder struct {
+	name   string
+	closed *[]string
+}
+
+func (c *closeRecorder) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return nil
+}
+
+func TestCloseProvidersClosesReplacedInstance(t *testing.T) {
+	a := newTestApp(t)
+	var closed []string
+	a.Provide("db", func() (interface{}, error) {
+		return &closeRecorder{name: "first", closed: &closed}, nil
+	})
+	if _, err := Resolve[*closeRecorder](a, "db"); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+
+	a.Provide("db", func() (interface{}, error) {
+		return &closeRecorder{name: "second", closed: &closed}, nil
+	})
+	db, err := Resolve[*closeRecorder](a, "db")
+	if err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	if db.name != "second" {
+		t.Errorf("Expected the replacement instance, got %s", db.name)
+	}
+
+	count, errs := a.closeProviders()
+	if count != 2 || len(errs) != 0 {
+		t.Errorf("Expected 2 closed dependencies without errors, got %d, %v", count, errs)
+	}
+	if len(closed) != 2 || closed[0] != "second" || closed[1] != "first" {
+		t.Errorf("Expected both instances closed in reverse order, got %v", closed)
+	}
+}
+
+func TestCloseProvidersConcurrentProvide(t *testing.T) {
+	a := newTestApp(t)
+	var closed []string
+	a.Provide("db", func() (interface{}, error) {
+		return &closeRecorder{name: "db", closed: &closed}, nil
+	})
+	if _, err := Resolve[*closeRecorder](a, "db"); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			a.Provide("other", func() (interface{}, error) { return i, nil })
+		}
+	}()
+	a.closeProviders()
+	<-done
+
+	if len(closed) != 1 {
+		t.Errorf("Expected db to be closed once, got %v", closed)
+	}
+}
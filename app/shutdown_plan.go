@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// planCommandName is the name of the hidden command printing the shutdown plan
+const planCommandName = "plan"
+
+// ShutdownPlan returns the names of the registered components in the order they are stopped
+// on shutdown, the reverse of their registration. It is purely introspective, nothing is stopped.
+func (a *App) ShutdownPlan() []string {
+	a.componentsMu.Lock()
+	defer a.componentsMu.Unlock()
+
+	plan := make([]string, 0, len(a.components))
+	for i := len(a.components) - 1; i >= 0; i-- {
+		plan = append(plan, a.components[i].name)
+	}
+	return plan
+}
+
+// planCommand returns the hidden command printing the shutdown plan without running anything
+func (a *App) planCommand() *cli.Command {
+	return &cli.Command{
+		Name:   planCommandName,
+		Usage:  "print the order components are stopped in on shutdown",
+		Hidden: true,
+		Action: func(c *cli.Context) error {
+			out := c.App.Writer
+			plan := a.ShutdownPlan()
+			fmt.Fprintf(out, "Shutdown plan, components stop within %s:\n", a.opt.ShutdownTimeout)
+			if len(plan) == 0 {
+				fmt.Fprintln(out, "  (no components registered before a command runs)")
+			}
+			for i, name := range plan {
+				fmt.Fprintf(out, "  %d. component %s\n", i+1, name)
+			}
+			fmt.Fprintln(out, "Then the after hooks run and shared dependencies are closed in reverse construction order")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestShutdownPlan(t *testing.T) {
+	a := newTestApp(t)
+	a.RegisterComponent("db", &fakeComponent{})
+	a.RegisterComponent("cache", &fakeComponent{})
+	a.RegisterComponent("http", &fakeComponent{})
+	// registering again keeps the position
+	a.RegisterComponent("db", &fakeComponent{})
+
+	want := []string{"http", "cache", "db"}
+	if plan := a.ShutdownPlan(); !slices.Equal(plan, want) {
+		t.Errorf("Expected %v, got %v", want, plan)
+	}
+}
+
+func TestPlanCommandSkipsInit(t *testing.T) {
+	var out bytes.Buffer
+	a := newTestApp(t)
+	a.app.Writer = &out
+	a.RegisterComponent("db", &fakeComponent{})
+	a.RegisterComponent("http", &fakeComponent{})
+
+	if err := a.app.RunContext(a.ctx, []string{"test", "plan"}); err != nil {
+		t.Fatalf("Failed to run plan: %v", err)
+	}
+	if !strings.Contains(out.String(), "1. component http\n  2. component db") {
+		t.Errorf("Expected the stop order, got %q", out.String())
+	}
+	if _, phases := a.Initialized(); len(phases) != 0 {
+		t.Errorf("Expected plan to skip initialization, got phases %v", phases)
+	}
+}
+
+func TestUserCommandInitializesWithBuiltinArgument(t *testing.T) {
+	var ran bool
+	a := newTestApp(t, WithCommands([]*cli.Command{{
+		Name: "deploy",
+		Action: func(c *cli.Context) error {
+			ran = true
+			return nil
+		},
+	}}))
+
+	if err := a.app.RunContext(a.ctx, []string{"test", "--config", "", "deploy", "plan"}); err != nil {
+		t.Fatalf("Failed to run deploy: %v", err)
+	}
+	if _, phases := a.Initialized(); !ran || !slices.Contains(phases, PhaseConfig) {
+		t.Errorf("Expected deploy to run with config, got phases %v", phases)
+	}
+}
+
+func TestUserCommandCollidingWithBuiltin(t *testing.T) {
+	for _, command := range []*cli.Command{
+		{Name: "plan", Action: func(c *cli.Context) error { return nil }},
+		{Name: "release", Aliases: []string{"completion"}, Action: func(c *cli.Context) error { return nil }},
+	} {
+		t.Run(command.Name, func(t *testing.T) {
+			a := newTestApp(t, WithCommands([]*cli.Command{command}))
+			err := a.checkBuiltinCommandNames()
+			if err == nil || !strings.Contains(err.Error(), "collides with the built-in") {
+				t.Errorf("Expected a collision error, got %v", err)
+			}
+			if err := a.app.RunContext(a.ctx, []string{"test", "--config", "", command.Name}); err == nil {
+				t.Error("Expected the colliding command not to run")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"time"
+)
+
+// AppSpec is a declarative app definition, an alternative to a long option chain in main().
+// The tags allow loading it from a config section with config.Manager.UnmarshalKey.
+type AppSpec struct {
+	Name    string `mapstructure:"name"`
+	Usage   string `mapstructure:"usage"`
+	Version string `mapstructure:"version"`
+
+	// ConfigFile is the default config file path, see WithConfigFile
+	ConfigFile string `mapstructure:"config_file"`
+	// EnvPrefix is the env var prefix, see WithEnvPrefix
+	EnvPrefix string `mapstructure:"env_prefix"`
+	// StrictEnv only honors explicitly bound env vars, see WithStrictEnv
+	StrictEnv bool `mapstructure:"strict_env"`
+	// EnvBindings maps config keys to env vars, see WithEnvBindings
+	EnvBindings map[string]string `mapstructure:"env_bindings"`
+	// RequiredKeys must be set for the app to start, see WithRequiredKeys
+	RequiredKeys []string `mapstructure:"required_keys"`
+
+	// LogLevel and LogFormat are the defaults of the log.level and log.format flags
+	LogLevel  string `mapstructure:"log_level"`
+	LogFormat string `mapstructure:"log_format"`
+
+	// StartupManifest is the path of the startup manifest, see WithStartupManifest
+	StartupManifest string `mapstructure:"startup_manifest"`
+	// RunDeadline bounds every command run, see WithRunDeadline
+	RunDeadline time.Duration `mapstructure:"run_deadline"`
+}
+
+// Options returns the options equivalent to the spec, zero fields are left at their defaults
+func (s AppSpec) Options() []Option {
+	var opts []Option
+	if s.ConfigFile != "" {
+		opts = append(opts, WithConfigFile(s.ConfigFile))
+	}
+	if s.EnvPrefix != "" {
+		opts = append(opts, WithEnvPrefix(s.EnvPrefix))
+	}
+	if s.StrictEnv {
+		opts = append(opts, WithStrictEnv())
+	}
+	for key, envVar := range s.EnvBindings {
+		opts = append(opts, AddEnvBinding(key, envVar))
+	}
+	if len(s.RequiredKeys) > 0 {
+		opts = append(opts, WithRequiredKeys(s.RequiredKeys...))
+	}
+	if s.LogLevel != "" || s.LogFormat != "" {
+		opts = append(opts, WithLogDefaults(s.LogLevel, s.LogFormat))
+	}
+	if s.StartupManifest != "" {
+		opts = append(opts, WithStartupManifest(s.StartupManifest))
+	}
+	if s.RunDeadline > 0 {
+		opts = append(opts, WithRunDeadline(s.RunDeadline))
+	}
+	return opts
+}
+
+// NewAppFromConfig creates an app from a spec. The spec options are applied by Init before
+// the options passed to it, so commands, flags and hooks are still added with options:
+//
+//	myApp := app.NewAppFromConfig(app.AppSpec{Name: "myapp", EnvPrefix: "MYAPP"})
+//	myApp.Init(app.WithCommands(commands))
+func NewAppFromConfig(spec AppSpec) *App {
+	a := NewApp(spec.Name, spec.Usage)
+	a.SetVersion(spec.Version)
+	a.specOpts = spec.Options()
+	return a
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/letusgogo/quick/config"
+)
+
+func TestAppSpecFromConfig(t *testing.T) {
+	manager := config.NewManager()
+	manager.SetConfigType("yaml")
+	spec := "app:\n  name: specd\n  env_prefix: SPECD\n  run_deadline: 5m\n  required_keys: [database.url, server.port]\n"
+	if err := manager.LoadFromReader(strings.NewReader(spec)); err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	var s AppSpec
+	if err := manager.UnmarshalKey("app", &s); err != nil {
+		t.Fatalf("Failed to unmarshal spec: %v", err)
+	}
+	a := NewAppFromConfig(s)
+	a.Init(WithRequiredKeys("log.level"))
+
+	if a.Name != "specd" || a.opt.EnvPrefix != "SPECD" || a.opt.RunDeadline != 5*time.Minute {
+		t.Errorf("Unexpected app from spec: name %q, env prefix %q, run deadline %s", a.Name, a.opt.EnvPrefix, a.opt.RunDeadline)
+	}
+	expected := []string{"database.url", "server.port", "log.level"}
+	if !slices.Equal(a.opt.RequiredKeys, expected) {
+		t.Errorf("Expected required keys %v, got %v", expected, a.opt.RequiredKeys)
+	}
+}
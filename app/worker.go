@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/letusgogo/quick/logger"
+)
+
+// Worker runs a function periodically until its context is cancelled. It is a Component,
+// so it can be registered with RegisterComponent instead of being run by hand.
+type Worker struct {
+	interval time.Duration
+	fn       func(ctx context.Context) error
+
+	// mu guards cancel and done of the worker started by Start
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// PeriodicWorker creates a worker calling fn on every interval tick. Errors and panics
+// of fn are logged and the worker keeps running; it stops once the context passed to Run is done.
+func PeriodicWorker(interval time.Duration, fn func(ctx context.Context) error) *Worker {
+	return &Worker{
+		interval: interval,
+		fn:       fn,
+	}
+}
+
+// Run calls fn on every tick and blocks until ctx is cancelled,
+// pass App.Context() to stop the worker when the app shuts down
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runOnce(ctx)
+		}
+	}
+}
+
+// Start runs the worker in a goroutine until ctx is done or Stop is called
+func (w *Worker) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		return errors.New("worker already started")
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	w.cancel, w.done = cancel, done
+	go func() {
+		defer close(done)
+		w.Run(runCtx)
+	}()
+	return nil
+}
+
+// Stop stops the worker started by Start and waits for a running fn to return,
+// returning early when ctx is done
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) runOnce(ctx context.Context) {
+	defer func() {
+		if e := recover(); e != nil {
+			logger.GetLogger("worker").Errorf("periodic worker crashed, err: %s stack:%s", e, string(debug.Stack()))
+		}
+	}()
+
+	if err := w.fn(ctx); err != nil && ctx.Err() == nil {
+		logger.GetLogger("worker").Errorf("periodic worker failed: %v", err)
+	}
+}
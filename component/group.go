@@ -0,0 +1,98 @@
+// Package component provides helpers for the lifecycle of long running parts of an app.
+package component
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStopTimeout is returned by Group.Stop when goroutines are still running after the timeout
+var ErrStopTimeout = errors.New("component stop timed out")
+
+// Group tracks the goroutines spawned by a component so they are joined when it stops.
+// Like errgroup, the first error cancels the group context; unlike errgroup, Stop bounds
+// the wait so a stuck goroutine cannot block shutdown forever.
+type Group struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running atomic.Int64
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup creates a group whose context derives from parent
+func NewGroup(parent context.Context) *Group {
+	ctx, cancel := context.WithCancel(parent)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the group context, cancelled by Stop or the first error
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs fn in a new goroutine with the group context. fn must return once the context
+// is done. A returned error or panic cancels the group and is reported by Wait and Stop.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	g.running.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.running.Add(-1)
+
+		if err := g.call(fn); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// call runs fn, turning a panic into an error
+func (g *Group) call(fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("goroutine panic: %v\n%s", e, debug.Stack())
+		}
+	}()
+	return fn(g.ctx)
+}
+
+// Wait waits for all goroutines and returns the first error
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+// Stop cancels the group context and waits up to timeout for all goroutines to return.
+// It returns the first error of a goroutine, or an error wrapping ErrStopTimeout
+// if some are still running. context.Canceled returned after Stop is not an error.
+func (g *Group) Stop(timeout time.Duration) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		return fmt.Errorf("%w: %d goroutines still running after %s", ErrStopTimeout, g.running.Load(), timeout)
+	}
+
+	if errors.Is(g.err, context.Canceled) {
+		return nil
+	}
+	return g.err
+}
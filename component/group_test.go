@@ -0,0 +1,51 @@
+package component
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroupStop(t *testing.T) {
+	g := NewGroup(context.Background())
+	for i := 0; i < 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	if err := g.Stop(time.Second); err != nil {
+		t.Errorf("Expected clean stop, got %v", err)
+	}
+
+	stuck := NewGroup(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	stuck.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	if err := stuck.Stop(10 * time.Millisecond); !errors.Is(err, ErrStopTimeout) {
+		t.Errorf("Expected ErrStopTimeout, got %v", err)
+	}
+}
+
+func TestGroupFirstErrorCancels(t *testing.T) {
+	g := NewGroup(context.Background())
+	failure := errors.New("boom")
+	g.Go(func(ctx context.Context) error {
+		return failure
+	})
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	g.Go(func(ctx context.Context) error {
+		panic("boom too")
+	})
+
+	if err := g.Wait(); err == nil {
+		t.Errorf("Expected an error from Wait")
+	}
+}
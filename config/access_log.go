@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Value sources reported by the access log, env sources are "env:" followed by the var name
+const (
+	SourceSet     = "set"
+	SourceFile    = "file"
+	SourceDefault = "default"
+	SourceUnset   = "unset"
+)
+
+// EnableAccessLog logs every Get* call at debug level with the key, the resolved value and its
+// source, e.g. to see which keys a startup path reads in which order and where each value comes
+// from. Values of sensitive keys are redacted. Until enabled, a Get costs one atomic load more.
+func (m *Manager) EnableAccessLog() {
+	m.accessLog.Store(true)
+}
+
+// logAccess logs a read of key resolved to value if the access log is enabled.
+// The caller holds m.mu for reading.
+func (m *Manager) logAccess(key string, value interface{}) {
+	if !m.accessLog.Load() || !m.log.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	if IsSensitiveKey(key) && value != nil {
+		value = RedactedValue
+	}
+	m.log.WithFields(logrus.Fields{
+		"key":    key,
+		"value":  value,
+		"source": m.valueSource(key),
+	}).Debug("config get")
+}
+
+// valueSource returns where the value of key comes from, following viper's precedence:
+// Set, env vars, the config file, defaults
+func (m *Manager) valueSource(key string) string {
+	lower := strings.ToLower(key)
+	if m.overrides[lower] {
+		return SourceSet
+	}
+
+	// like viper, automatic env lookup comes before explicit bindings and empty vars are unset
+	if m.automaticEnv {
+		envVar := strings.ToUpper(strings.ReplaceAll(lower, ".", "_"))
+		if prefix := m.viper.GetEnvPrefix(); prefix != "" {
+			envVar = strings.ToUpper(prefix) + "_" + envVar
+		}
+		if os.Getenv(envVar) != "" {
+			return "env:" + envVar
+		}
+	}
+	if envVars, ok := m.envBindings[lower]; ok {
+		for _, envVar := range strings.Split(envVars, ",") {
+			if os.Getenv(envVar) != "" {
+				return "env:" + envVar
+			}
+		}
+	}
+
+	if m.viper.InConfig(lower) {
+		return SourceFile
+	}
+	if m.viper.IsSet(lower) {
+		return SourceDefault
+	}
+	return SourceUnset
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Audit sources recorded in the "source" field of audit entries
+const (
+	AuditSourceSet        = "set"
+	AuditSourceFileReload = "file reload"
+)
+
+// RedactedValue replaces the value of sensitive keys and fields wherever values are logged
+const RedactedValue = "******"
+
+// sensitiveKeyParts are substrings that mark a key as sensitive, compared without separators
+var sensitiveKeyParts = []string{"password", "passwd", "secret", "token", "credential", "privatekey", "apikey", "accesskey"}
+
+// EnableAuditLog enables audit logging of runtime configuration changes, by Set and by Watch
+// reloading the config file. Every change is written to w as a JSON line with key, old value, new value and source.
+// Values of sensitive keys (password, secret, token...) are redacted.
+func (m *Manager) EnableAuditLog(w io.Writer) {
+	auditLog := logrus.New()
+	auditLog.SetOutput(w)
+	auditLog.SetLevel(logrus.InfoLevel)
+	auditLog.SetFormatter(&logrus.JSONFormatter{
+		TimestampFormat: "2006-01-02 15:04:05",
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.auditLog = auditLog
+}
+
+// audit writes an audit entry for a configuration change if audit logging is enabled,
+// the caller must hold the lock
+func (m *Manager) audit(key string, oldValue, newValue interface{}, source string) {
+	if m.auditLog == nil {
+		return
+	}
+
+	if IsSensitiveKey(key) {
+		if oldValue != nil {
+			oldValue = RedactedValue
+		}
+		if newValue != nil {
+			newValue = RedactedValue
+		}
+	}
+
+	m.auditLog.WithFields(logrus.Fields{
+		"module": "config.audit",
+		"key":    key,
+		"old":    oldValue,
+		"new":    newValue,
+		"source": source,
+	}).Info("config value changed")
+}
+
+// auditChanges writes an audit entry for every key changed from before to after, both
+// flattened configs; added keys have no old value and removed keys no new value
+func (m *Manager) auditChanges(before, after map[string]string, source string) {
+	if m.auditLog == nil {
+		return
+	}
+	for _, change := range diffFlat(before, after) {
+		var oldValue, newValue interface{}
+		if change.Kind != ChangeAdded {
+			oldValue = change.Old
+		}
+		if change.Kind != ChangeRemoved {
+			newValue = change.New
+		}
+		m.audit(change.Key, oldValue, newValue, source)
+	}
+}
+
+// IsSensitiveKey reports whether a configuration key, or a field or JSON name, likely holds a
+// secret. Case, underscores and dashes are ignored, so private_key, privateKey and
+// PrivateKey all match.
+func IsSensitiveKey(key string) bool {
+	lower := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
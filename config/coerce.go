@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// GetBoolE returns a boolean configuration value.
+// Strings (e.g. from env vars) are trimmed and matched case-insensitively:
+// true/yes/on/y/t/1 are true, false/no/off/n/f/0 and empty are false.
+// Any other string returns an error.
+func (m *Manager) GetBoolE(key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value := m.viper.Get(key)
+	m.logAccess(key, value)
+	s, ok := value.(string)
+	if !ok {
+		return cast.ToBoolE(value)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "y", "t", "1":
+		return true, nil
+	case "false", "no", "off", "n", "f", "0", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid boolean value for %s: %q", key, s)
+	}
+}
+
+// GetIntE returns an integer configuration value.
+// Strings are trimmed and always parsed as base 10, so "007" is 7 rather than an octal literal.
+// Non-numeric strings return an error.
+func (m *Manager) GetIntE(key string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value := m.viper.Get(key)
+	m.logAccess(key, value)
+	s, ok := value.(string)
+	if !ok {
+		return cast.ToIntE(value)
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(s, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer value for %s: %q", key, s)
+	}
+	return int(i), nil
+}
+
+// GetEnum returns the value of key if it is one of allowed, matched case-insensitively after
+// trimming, in the casing of allowed. An absent or empty value returns def.
+// Any other value returns an error listing the valid values.
+func (m *Manager) GetEnum(key string, allowed []string, def string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value := strings.TrimSpace(m.viper.GetString(key))
+	m.logAccess(key, value)
+	if value == "" {
+		return def, nil
+	}
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %q, valid values: %s", key, value, strings.Join(allowed, ", "))
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -10,8 +16,39 @@ import (
 
 // Manager handles configuration management with support for file and environment variable overrides
 type Manager struct {
-	viper *viper.Viper
-	log   *logrus.Entry
+	viper      *viper.Viper
+	log        *logrus.Entry
+	auditLog   *logrus.Logger
+	configType string
+	// raw is the loaded config file with original key casing
+	raw map[string]interface{}
+	// envPrefixes are the global env var prefixes checked in order, see SetEnvPrefixes
+	envPrefixes []string
+	// prefixBindings marks keys bound by SetEnvPrefixes rather than explicitly
+	prefixBindings map[string]bool
+	// subtreeEnvPrefixes maps config subtrees to their own env var prefix
+	subtreeEnvPrefixes map[string]string
+	// envBindings records every explicit config key to env var binding
+	envBindings map[string]string
+	// strictEnv disables automatic env lookup, only explicit bindings are honored
+	strictEnv    bool
+	automaticEnv bool
+	// validators are the custom validations run by Validate
+	validators []func(m *Manager) error
+	// slowLoadThreshold is the load duration logged as a warning, see SetSlowLoadThreshold
+	slowLoadThreshold time.Duration
+	// lastLoadDuration is the duration of the last LoadFromFile in nanoseconds
+	lastLoadDuration atomic.Int64
+
+	// mu guards the viper settings against reloads by Watch
+	mu sync.RWMutex
+	// accessLog enables logging every Get, see EnableAccessLog
+	accessLog atomic.Bool
+	// overrides are the keys set by Set, the highest precedence source
+	overrides map[string]bool
+	// configFile is the local file last loaded by LoadFromFile, watched by Watch
+	configFile string
+	watch      *watchState
 }
 
 // NewManager creates a new configuration manager
@@ -29,78 +66,321 @@ func (m *Manager) Viper() *viper.Viper {
 }
 
 func (m *Manager) Set(key, value string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldValue := m.viper.Get(key)
 	m.viper.Set(key, value)
+	if m.overrides == nil {
+		m.overrides = make(map[string]bool)
+	}
+	m.overrides[strings.ToLower(key)] = true
+	m.audit(key, oldValue, value, AuditSourceSet)
 }
 
-// LoadFromFile loads configuration from a file
+// LoadFromFile loads configuration from a file, merging the files listed under IncludeKey.
+// An http or https URL is fetched with LoadFromURL, authenticated with the bearer token
+// in the BearerTokenEnv env var if set. The load is timed, see LastLoadDuration.
 func (m *Manager) LoadFromFile(configFile string) error {
+	defer m.timeLoad(configFile, time.Now())
+
+	if isURL(configFile) {
+		return m.LoadFromURL(configFile, WithBearerToken(os.Getenv(BearerTokenEnv)))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.loadFile(configFile)
+}
+
+// loadFile loads configuration from a file, see LoadFromFile
+func (m *Manager) loadFile(configFile string) error {
 	if configFile == "" {
 		m.log.Warn("No config file specified")
 		return nil
 	}
 
+	if m.configType == ConfigTypeJSONC || strings.EqualFold(filepath.Ext(configFile), "."+ConfigTypeJSONC) {
+		m.configFile = configFile
+		if err := m.loadJSONCFile(configFile); err != nil {
+			return err
+		}
+		return m.applyIncludes(configFile)
+	}
+
+	m.configFile = configFile
 	m.viper.SetConfigFile(configFile)
 	if err := m.viper.ReadInConfig(); err != nil {
 		m.log.Warnf("Config file not found: %s, using environment variables", configFile)
 		return err
 	}
+	if data, err := os.ReadFile(configFile); err == nil {
+		m.raw = parseRaw(data, configTypeOf(configFile))
+	}
+	m.applyEnvBindings()
+
+	m.log.Infof("Loaded config from file: %s", configFile)
+	return m.applyIncludes(configFile)
+}
+
+// loadJSONCFile loads a JSON file with comments
+func (m *Manager) loadJSONCFile(configFile string) error {
+	f, err := os.Open(configFile)
+	if err != nil {
+		m.log.Warnf("Config file not found: %s, using environment variables", configFile)
+		return err
+	}
+	defer f.Close()
+
+	if err := m.loadReader(f, ConfigTypeJSONC); err != nil {
+		return err
+	}
 
 	m.log.Infof("Loaded config from file: %s", configFile)
 	return nil
 }
 
+// SetConfigType sets the format used by LoadFromReader and forces the format of LoadFromFile,
+// e.g. "yaml", "json" or "jsonc" (JSON with comments and trailing commas)
+func (m *Manager) SetConfigType(configType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configType = strings.ToLower(configType)
+	if m.configType != ConfigTypeJSONC {
+		m.viper.SetConfigType(m.configType)
+	}
+}
+
+// LoadFromReader loads configuration from a reader in the format set by SetConfigType
+func (m *Manager) LoadFromReader(in io.Reader) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.loadReader(in, m.configType)
+}
+
+func (m *Manager) loadReader(in io.Reader, configType string) error {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	viperType := configType
+	viperData := data
+	if configType == ConfigTypeJSONC {
+		viperData = stripJSONC(data)
+		viperType = "json"
+	}
+
+	m.viper.SetConfigType(viperType)
+	if err := m.viper.ReadConfig(bytes.NewReader(viperData)); err != nil {
+		return err
+	}
+	m.raw = parseRaw(data, configType)
+	m.applyEnvBindings()
+	return nil
+}
+
 // SetupEnvironmentOverrides sets up environment variable overrides using Viper's built-in support
 func (m *Manager) SetupEnvironmentOverrides() {
-	// Enable automatic environment variable lookup
-	m.viper.AutomaticEnv()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Enable automatic environment variable lookup, unless only explicit bindings are allowed
+	if !m.strictEnv {
+		m.viper.AutomaticEnv()
+		m.automaticEnv = true
+	}
 
 	// Replace dots with underscores for environment variable names
 	// Example: server.port -> SERVER_PORT (when using prefix)
 	m.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 }
 
+// SetStrictEnv enables strict env mode: automatic env lookup is disabled and only keys bound
+// explicitly (BindEnv, BindEnvs, BindStructEnv, BindEnvPrefix) are read from the environment.
+// EnvBindings then lists the closed set of honored env vars.
+// It must be called before SetupEnvironmentOverrides, since viper cannot turn automatic lookup off.
+func (m *Manager) SetStrictEnv(strict bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if strict && m.automaticEnv {
+		m.log.Warn("Strict env mode enabled after automatic env lookup was set up, it has no effect")
+	}
+	m.strictEnv = strict
+}
+
+// EnvBindings returns a copy of the explicit config key to env var bindings.
+// Keys bound to several env vars (SetEnvPrefixes) list them comma separated in precedence order.
+func (m *Manager) EnvBindings() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bindings := make(map[string]string, len(m.envBindings))
+	for key, envVar := range m.envBindings {
+		bindings[key] = envVar
+	}
+	return bindings
+}
+
 // SetEnvPrefix sets a prefix for environment variables
 // Example: SetEnvPrefix("APP") means APP_SERVER_PORT maps to server.port
 func (m *Manager) SetEnvPrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.setEnvPrefix(prefix)
+}
+
+// setEnvPrefix is SetEnvPrefix, the caller must hold the lock
+func (m *Manager) setEnvPrefix(prefix string) {
 	m.viper.SetEnvPrefix(prefix)
 	m.log.Infof("Environment variable prefix set to: %s", prefix)
 }
 
+// BindEnvPrefix makes keys under configPrefix resolve from env vars with envPrefix instead of
+// the global prefix. The rest of the key is upper-cased with dots replaced by underscores and
+// appended to envPrefix as is. Example: BindEnvPrefix("database", "PG") maps database.host to
+// PGHOST, BindEnvPrefix("database", "DB_") maps it to DB_HOST.
+// Keys present in the config file are bound, and so are env vars made of envPrefix, an
+// underscore and the rest of the key, so PG binds PG_PORT but not PGDATA. Bindings are
+// refreshed when a config file is loaded. Empty prefixes are ignored with a warning.
+func (m *Manager) BindEnvPrefix(configPrefix, envPrefix string) {
+	if configPrefix == "" || envPrefix == "" {
+		m.log.Warnf("Ignoring env prefix binding with an empty prefix: %q -> %q", configPrefix, envPrefix)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.subtreeEnvPrefixes == nil {
+		m.subtreeEnvPrefixes = make(map[string]string)
+	}
+	m.subtreeEnvPrefixes[strings.ToLower(configPrefix)] = envPrefix
+	m.bindSubtreeEnvPrefix(strings.ToLower(configPrefix), envPrefix)
+}
+
+// applyEnvBindings re-binds env prefixes and subtree env prefixes, e.g. after new keys were loaded.
+// Subtree prefixes are bound last, so they win over SetEnvPrefixes.
+func (m *Manager) applyEnvBindings() {
+	m.applyEnvPrefixes()
+	for configPrefix, envPrefix := range m.subtreeEnvPrefixes {
+		m.bindSubtreeEnvPrefix(configPrefix, envPrefix)
+	}
+}
+
+func (m *Manager) bindSubtreeEnvPrefix(configPrefix, envPrefix string) {
+	// Keys known from the config file
+	for _, key := range m.viper.AllKeys() {
+		if !strings.HasPrefix(key, configPrefix+".") {
+			continue
+		}
+		rest := strings.TrimPrefix(key, configPrefix+".")
+		m.bindEnv(key, envPrefix+strings.ToUpper(strings.ReplaceAll(rest, ".", "_")))
+	}
+
+	// Keys only present in the environment, the prefix must be followed by an underscore
+	separated := strings.TrimSuffix(envPrefix, "_") + "_"
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		rest, ok := strings.CutPrefix(name, separated)
+		if !ok || rest == "" {
+			continue
+		}
+		m.bindEnv(configPrefix+"."+strings.ToLower(strings.ReplaceAll(rest, "_", ".")), name)
+	}
+}
+
 // BindEnv binds environment variables to configuration keys
 func (m *Manager) BindEnv(key, envVar string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bindEnv(key, envVar)
+}
+
+// bindEnv is BindEnv, the caller must hold the lock
+func (m *Manager) bindEnv(key, envVar string) {
+	if m.envBindings == nil {
+		m.envBindings = make(map[string]string)
+	}
+	m.envBindings[strings.ToLower(key)] = envVar
 	m.viper.BindEnv(key, envVar)
 }
 
 // BindEnvs binds multiple environment variables to configuration keys
 func (m *Manager) BindEnvs(bindings map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for key, envVar := range bindings {
-		m.viper.BindEnv(key, envVar)
+		m.bindEnv(key, envVar)
 	}
 }
 
 // GetString returns a string configuration value
 func (m *Manager) GetString(key string) string {
-	return m.viper.GetString(key)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value := m.viper.GetString(key)
+	m.logAccess(key, value)
+	return value
 }
 
-// GetInt returns an integer configuration value
+// GetInt returns an integer configuration value, or 0 if it is invalid (see GetIntE)
 func (m *Manager) GetInt(key string) int {
-	return m.viper.GetInt(key)
+	i, _ := m.GetIntE(key)
+	return i
 }
 
-// GetBool returns a boolean configuration value
+// GetBool returns a boolean configuration value, or false if it is invalid (see GetBoolE)
 func (m *Manager) GetBool(key string) bool {
-	return m.viper.GetBool(key)
+	b, _ := m.GetBoolE(key)
+	return b
 }
 
 // GetStringSlice returns a string slice configuration value
 func (m *Manager) GetStringSlice(key string) []string {
-	return m.viper.GetStringSlice(key)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value := m.viper.GetStringSlice(key)
+	m.logAccess(key, value)
+	return value
 }
 
-// UnmarshalKey unmarshals a configuration key into a struct
+// GetDuration returns a duration configuration value, e.g. "30s"
+func (m *Manager) GetDuration(key string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value := m.viper.GetDuration(key)
+	m.logAccess(key, value)
+	return value
+}
+
+// GetFloat64 returns a float configuration value
+func (m *Manager) GetFloat64(key string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value := m.viper.GetFloat64(key)
+	m.logAccess(key, value)
+	return value
+}
+
+// GetStringMapString returns a nested map configuration value with string values,
+// e.g. header overrides. Like all viper keys, the map keys are lowercased.
+func (m *Manager) GetStringMapString(key string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value := m.viper.GetStringMapString(key)
+	m.logAccess(key, value)
+	return value
+}
+
+// UnmarshalKey unmarshals a configuration key into a struct.
+// Nested keys bound to env vars (see BindStructEnv) override the file values.
 func (m *Manager) UnmarshalKey(key string, rawVal interface{}) error {
-	return m.viper.UnmarshalKey(key, rawVal)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return decode(m.settingsAt(key), rawVal)
 }
 
 // UnmarshalKeyWithEnv unmarshals a configuration key into a struct
@@ -108,18 +388,22 @@ func (m *Manager) UnmarshalKey(key string, rawVal interface{}) error {
 // envMappings: map[configKey]envVar (e.g., map["server.port"]="SERVER_PORT")
 func (m *Manager) UnmarshalKeyWithEnv(key string, rawVal interface{}, envMappings map[string]string) error {
 	// Auto-sync environment variables directly
+	m.mu.Lock()
 	for configKey, envVar := range envMappings {
 		if envValue := os.Getenv(envVar); envValue != "" {
 			m.viper.Set(configKey, envValue)
 			m.log.Debugf("Synced env %s=%s to config %s", envVar, envValue, configKey)
 		}
 	}
-	return m.viper.UnmarshalKey(key, rawVal)
+	m.mu.Unlock()
+	return m.UnmarshalKey(key, rawVal)
 }
 
 // Unmarshal unmarshals the entire configuration into a struct
 func (m *Manager) Unmarshal(rawVal interface{}) error {
-	return m.viper.Unmarshal(rawVal)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return decode(m.settingsAt(""), rawVal)
 }
 
 // GetViper returns the underlying viper instance for advanced usage
@@ -129,6 +413,9 @@ func (m *Manager) GetViper() *viper.Viper {
 
 // LogConfigValue logs a configuration value for debugging
 func (m *Manager) LogConfigValue(key string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	value := m.viper.GetString(key)
 	envVar := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
 	envValue := os.Getenv(envVar)
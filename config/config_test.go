@@ -1,8 +1,21 @@
 package config
 
 import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
 )
 
 func TestEnvironmentVariableOverrides(t *testing.T) {
@@ -65,3 +78,1023 @@ func TestManualEnvironmentBinding(t *testing.T) {
 		t.Errorf("Expected server.port to be '3000', got '%s'", port)
 	}
 }
+
+func TestAuditLogRedactsSensitiveKeys(t *testing.T) {
+	var buf bytes.Buffer
+
+	manager := NewManager()
+	manager.EnableAuditLog(&buf)
+
+	manager.Set("server.port", "8080")
+	manager.Set("server.port", "9090")
+	manager.Set("database.password", "hunter2")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %d: %s", len(lines), buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Failed to parse audit entry: %v", err)
+	}
+	if entry["key"] != "server.port" || entry["old"] != "8080" || entry["new"] != "9090" || entry["source"] != AuditSourceSet {
+		t.Errorf("Unexpected audit entry: %v", entry)
+	}
+
+	if strings.Contains(lines[2], "hunter2") {
+		t.Errorf("Expected sensitive value to be redacted, got %s", lines[2])
+	}
+}
+
+func TestEnableAuditLogWhileSetting(t *testing.T) {
+	manager := NewManager()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			manager.Set("server.port", strconv.Itoa(i))
+		}
+	}()
+
+	var buf bytes.Buffer
+	manager.EnableAuditLog(&buf)
+	<-done
+	manager.Set("server.port", "8080")
+	if !strings.Contains(buf.String(), `"new":"8080"`) {
+		t.Errorf("Expected the audit log to record the change, got %s", buf.String())
+	}
+}
+
+func TestUnmarshalAndValidate(t *testing.T) {
+	type ServerConfig struct {
+		Port string `mapstructure:"port" validate:"required"`
+		Host string `mapstructure:"host"`
+	}
+	type Config struct {
+		Server ServerConfig `mapstructure:"server"`
+	}
+
+	manager := NewManager()
+	manager.Set("server.host", "localhost")
+
+	var cfg Config
+	err := manager.UnmarshalAndValidate(&cfg)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Fields) != 1 || validationErr.Fields[0].Key != "server.port" || validationErr.Fields[0].Tag != "required" {
+		t.Errorf("Unexpected field errors: %+v", validationErr.Fields)
+	}
+
+	manager.Set("server.port", "8080")
+	if err := manager.UnmarshalAndValidate(&cfg); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+}
+
+func TestUnmarshalKeyValidated(t *testing.T) {
+	type ServerConfig struct {
+		Port    int    `mapstructure:"port" validate:"required,min=1"`
+		Host    string `mapstructure:"host" validate:"required"`
+		Workers int    `mapstructure:"workers" validate:"max=64"`
+	}
+
+	manager := NewManager()
+	manager.Set("server.port", "0")
+	manager.Set("server.workers", "100")
+
+	var cfg ServerConfig
+	err := manager.UnmarshalKeyValidated("server", &cfg)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected ValidationError, got %v", err)
+	}
+	expected := "invalid config: server.port: failed on 'required'; server.host: failed on 'required'; server.workers: failed on 'max=64'"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+
+	manager.Set("server.port", "8080")
+	manager.Set("server.host", "localhost")
+	manager.Set("server.workers", "8")
+	if err := manager.UnmarshalKeyValidated("server", &cfg); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+}
+
+func TestLoadJSONC(t *testing.T) {
+	input := `{
+	// line comment
+	"server": {
+		"host": "http://localhost", /* inline block */
+		"port": "8080",
+	},
+	/*
+	 * block comment
+	 */
+	"paths": ["/a", "/b",],
+	"note": "keep // this and /* this */",
+}`
+
+	manager := NewManager()
+	manager.SetConfigType("jsonc")
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load jsonc: %v", err)
+	}
+
+	if host := manager.GetString("server.host"); host != "http://localhost" {
+		t.Errorf("Expected server.host to be 'http://localhost', got '%s'", host)
+	}
+	if port := manager.GetString("server.port"); port != "8080" {
+		t.Errorf("Expected server.port to be '8080', got '%s'", port)
+	}
+	if paths := manager.GetStringSlice("paths"); len(paths) != 2 {
+		t.Errorf("Expected 2 paths, got %v", paths)
+	}
+	if note := manager.GetString("note"); note != "keep // this and /* this */" {
+		t.Errorf("Expected comment markers inside strings to be kept, got '%s'", note)
+	}
+}
+
+func TestGetRawMapPreservesCase(t *testing.T) {
+	input := `
+http:
+  headers:
+    X-Api-Key: secret
+    Content-Type: application/json
+`
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	headers := manager.GetRawMap("http.headers")
+	if headers["X-Api-Key"] != "secret" {
+		t.Errorf("Expected X-Api-Key to keep its casing, got %v", headers)
+	}
+	if manager.GetRawMap("HTTP.Headers") == nil {
+		t.Error("Expected key path to match case-insensitively")
+	}
+	if manager.GetRawMap("http.missing") != nil {
+		t.Error("Expected nil for missing key")
+	}
+}
+
+func TestBindEnvPrefix(t *testing.T) {
+	os.Setenv("APP_SERVER_PORT", "9090")
+	os.Setenv("PGHOST", "db.internal")
+	os.Setenv("PG_PORT", "6432")
+	os.Setenv("PGDATA", "/var/lib/postgresql")
+	defer os.Unsetenv("APP_SERVER_PORT")
+	defer os.Unsetenv("PGHOST")
+	defer os.Unsetenv("PG_PORT")
+	defer os.Unsetenv("PGDATA")
+
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader("database:\n  host: localhost\n")); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	manager.SetEnvPrefix("APP")
+	manager.SetupEnvironmentOverrides()
+	manager.BindEnvPrefix("database", "PG")
+
+	if host := manager.GetString("database.host"); host != "db.internal" {
+		t.Errorf("Expected database.host to be 'db.internal', got '%s'", host)
+	}
+	if port := manager.GetString("database.port"); port != "6432" {
+		t.Errorf("Expected database.port to be '6432', got '%s'", port)
+	}
+	if data := manager.GetString("database.data"); data != "" {
+		t.Errorf("Expected PGDATA not to match the PG prefix, got '%s'", data)
+	}
+	if port := manager.GetString("server.port"); port != "9090" {
+		t.Errorf("Expected server.port to be '9090', got '%s'", port)
+	}
+
+	manager.BindEnvPrefix("cache", "")
+	if _, bound := manager.EnvBindings()["cache.app.server.port"]; bound {
+		t.Error("Expected an empty env prefix to be ignored")
+	}
+}
+
+func TestEnvValueCoercion(t *testing.T) {
+	os.Setenv("TEST_FEATURE_ENABLED", " Yes ")
+	os.Setenv("TEST_FEATURE_DEBUG", "off")
+	os.Setenv("TEST_FEATURE_BROKEN", "maybe")
+	os.Setenv("TEST_WORKER_COUNT", " 010 ")
+	defer os.Unsetenv("TEST_FEATURE_ENABLED")
+	defer os.Unsetenv("TEST_FEATURE_DEBUG")
+	defer os.Unsetenv("TEST_FEATURE_BROKEN")
+	defer os.Unsetenv("TEST_WORKER_COUNT")
+
+	manager := NewManager()
+	manager.SetEnvPrefix("TEST")
+	manager.SetupEnvironmentOverrides()
+
+	if !manager.GetBool("feature.enabled") {
+		t.Error("Expected feature.enabled to be true")
+	}
+	if manager.GetBool("feature.debug") {
+		t.Error("Expected feature.debug to be false")
+	}
+	if _, err := manager.GetBoolE("feature.broken"); err == nil {
+		t.Error("Expected error for invalid boolean")
+	}
+	if count := manager.GetInt("worker.count"); count != 10 {
+		t.Errorf("Expected worker.count to be 10, got %d", count)
+	}
+}
+
+func TestBindStructEnv(t *testing.T) {
+	type TLSConfig struct {
+		CertFile string `mapstructure:"cert_file"`
+	}
+	type ServerConfig struct {
+		Port string    `mapstructure:"port"`
+		Host string    `mapstructure:"host"`
+		TLS  TLSConfig `mapstructure:"tls"`
+	}
+
+	os.Setenv("SERVER_PORT", "9090")
+	os.Setenv("SERVER_TLS_CERT_FILE", "/etc/cert.pem")
+	defer os.Unsetenv("SERVER_PORT")
+	defer os.Unsetenv("SERVER_TLS_CERT_FILE")
+
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader("server:\n  host: localhost\n  port: \"8080\"\n")); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	var cfg ServerConfig
+	manager.BindStructEnv("server", &cfg)
+	if err := manager.UnmarshalKey("server", &cfg); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	if cfg.Port != "9090" {
+		t.Errorf("Expected port from env to be '9090', got '%s'", cfg.Port)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("Expected host from file to be 'localhost', got '%s'", cfg.Host)
+	}
+	if cfg.TLS.CertFile != "/etc/cert.pem" {
+		t.Errorf("Expected nested cert_file to be '/etc/cert.pem', got '%s'", cfg.TLS.CertFile)
+	}
+}
+
+func TestStrictEnv(t *testing.T) {
+	os.Setenv("TEST_SERVER_PORT", "9090")
+	os.Setenv("TEST_SERVER_HOST", "example.com")
+	defer os.Unsetenv("TEST_SERVER_PORT")
+	defer os.Unsetenv("TEST_SERVER_HOST")
+
+	manager := NewManager()
+	manager.SetEnvPrefix("TEST")
+	manager.SetStrictEnv(true)
+	manager.SetupEnvironmentOverrides()
+	manager.BindEnv("server.host", "TEST_SERVER_HOST")
+
+	if port := manager.GetString("server.port"); port != "" {
+		t.Errorf("Expected undeclared server.port to be empty, got '%s'", port)
+	}
+	if host := manager.GetString("server.host"); host != "example.com" {
+		t.Errorf("Expected server.host to be 'example.com', got '%s'", host)
+	}
+	if bindings := manager.EnvBindings(); len(bindings) != 1 || bindings["server.host"] != "TEST_SERVER_HOST" {
+		t.Errorf("Unexpected env bindings: %v", bindings)
+	}
+}
+
+func TestFlattenAndExportEnv(t *testing.T) {
+	input := `
+server:
+  port: 8080
+  tls:
+    enabled: true
+hosts: [a, b]
+`
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	flat := manager.Flatten()
+	expected := map[string]string{
+		"server.port":        "8080",
+		"server.tls.enabled": "true",
+		"hosts":              "a,b",
+	}
+	for key, value := range expected {
+		if flat[key] != value {
+			t.Errorf("Expected %s to be '%s', got '%s'", key, value, flat[key])
+		}
+	}
+
+	env := manager.ExportEnv("app")
+	if env["APP_SERVER_TLS_ENABLED"] != "true" {
+		t.Errorf("Expected APP_SERVER_TLS_ENABLED to be 'true', got %v", env)
+	}
+
+	entries := manager.EnvForExec("app")
+	if len(entries) != 3 || entries[0] != "APP_HOSTS=a,b" || entries[2] != "APP_SERVER_TLS_ENABLED=true" {
+		t.Errorf("Unexpected exec env: %v", entries)
+	}
+}
+
+func TestTLSConfigValidation(t *testing.T) {
+	manager := NewManager()
+	manager.Set("server.tls.min_version", "1.3")
+	manager.Set("server.tls.cipher_suites", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+
+	tlsConfig, err := TLSConfig(manager, "server.tls")
+	if err != nil {
+		t.Fatalf("Failed to build tls config: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS13 || len(tlsConfig.CipherSuites) != 1 {
+		t.Errorf("Unexpected tls config: %+v", tlsConfig)
+	}
+
+	manager.Set("server.tls.cert_file", "/etc/cert.pem")
+	if _, err := TLSConfig(manager, "server.tls"); err == nil {
+		t.Error("Expected error for cert_file without key_file")
+	}
+
+	manager = NewManager()
+	manager.Set("server.tls.min_version", "1.4")
+	if _, err := TLSConfig(manager, "server.tls"); err == nil {
+		t.Error("Expected error for invalid min_version")
+	}
+}
+
+func TestUnusedKeys(t *testing.T) {
+	type ServerConfig struct {
+		Port string `mapstructure:"port"`
+		Host string `mapstructure:"host"`
+	}
+
+	input := `
+server:
+  port: "8080"
+  hots: localhost
+log:
+  level: info
+legacy:
+  enabled: true
+`
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	known := append(StructKeys("server", &ServerConfig{}), "log")
+	unused := manager.UnusedKeys(known)
+	if len(unused) != 2 || unused[0] != "legacy.enabled" || unused[1] != "server.hots" {
+		t.Errorf("Unexpected unused keys: %v", unused)
+	}
+}
+
+func TestConfigIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := dir + "/" + name
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	write("db.yaml", "database:\n  host: db.internal\n  port: 5432\n")
+	write("common.yaml", "include: [db.yaml]\ndatabase:\n  port: 6432\nlog:\n  level: debug\n")
+	main := write("main.yaml", "include: [common.yaml]\nlog:\n  level: info\n")
+
+	manager := NewManager()
+	if err := manager.LoadFromFile(main); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if got := manager.GetString("database.host"); got != "db.internal" {
+		t.Errorf("Expected database.host from nested include, got %q", got)
+	}
+	if got := manager.GetInt("database.port"); got != 6432 {
+		t.Errorf("Expected including file to override database.port, got %d", got)
+	}
+	if got := manager.GetString("log.level"); got != "info" {
+		t.Errorf("Expected main file to override log.level, got %q", got)
+	}
+
+	// env vars, defaults and Set values stay in their own layers
+	t.Setenv("LOG_LEVEL", "warn")
+	layered := NewManager()
+	layered.SetupEnvironmentOverrides()
+	layered.Viper().SetDefault("metrics.enabled", true)
+	if err := layered.LoadFromFile(main); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if got := layered.GetString("log.level"); got != "warn" {
+		t.Errorf("Expected env var to override log.level, got %q", got)
+	}
+	os.Unsetenv("LOG_LEVEL")
+	if got := layered.GetString("log.level"); got != "info" {
+		t.Errorf("Expected log.level from the file once the env var is unset, got %q", got)
+	}
+	if unused := layered.UnusedKeys([]string{"include", "database", "log"}); len(unused) != 0 {
+		t.Errorf("Expected defaults not to be reported as config file keys, got %v", unused)
+	}
+
+	// includes only come from the file, an env var cannot inject one
+	write("secrets.yaml", "database:\n  password: injected\n")
+	t.Setenv("INCLUDE", "secrets.yaml")
+	for _, file := range []string{write("plain.yaml", "log:\n  level: info\n"), main} {
+		injected := NewManager()
+		injected.SetupEnvironmentOverrides()
+		if err := injected.LoadFromFile(file); err != nil {
+			t.Fatalf("Failed to load config: %v", err)
+		}
+		if got := injected.GetString("database.password"); got != "" {
+			t.Errorf("Expected the INCLUDE env var to be ignored for %s, got database.password %q", file, got)
+		}
+	}
+	os.Unsetenv("INCLUDE")
+
+	write("a.yaml", "include: [b.yaml]\n")
+	write("b.yaml", "include: [a.yaml]\n")
+	err := NewManager().LoadFromFile(dir + "/a.yaml")
+	if err == nil || !strings.Contains(err.Error(), "circular config include") {
+		t.Errorf("Expected circular include error, got %v", err)
+	}
+}
+
+func TestTypedValues(t *testing.T) {
+	input := `
+port: 8080
+name: "8080"
+timeout: null
+tags: [a, b]
+`
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	if got := manager.Kind("port"); got != reflect.Int {
+		t.Errorf("Expected port to be int, got %v", got)
+	}
+	if got := manager.Kind("name"); got != reflect.String {
+		t.Errorf("Expected name to be string, got %v", got)
+	}
+	if got := manager.Kind("tags"); got != reflect.Slice {
+		t.Errorf("Expected tags to be slice, got %v", got)
+	}
+	if !manager.IsNull("timeout") {
+		t.Errorf("Expected timeout to be null")
+	}
+	if manager.IsNull("missing") || manager.IsNull("port") {
+		t.Errorf("Expected only explicit nulls to be null")
+	}
+	if got := manager.Get("missing"); got != nil {
+		t.Errorf("Expected nil for a missing key, got %v", got)
+	}
+}
+
+func TestIndexedEnvList(t *testing.T) {
+	type Server struct {
+		Host string `mapstructure:"host"`
+		Port int    `mapstructure:"port"`
+	}
+	type Config struct {
+		Servers []Server `mapstructure:"servers"`
+		Tags    []string `mapstructure:"tags"`
+	}
+
+	input := `
+servers:
+  - host: a.internal
+    port: 80
+tags: []
+`
+	t.Setenv("IDX_SERVERS_0_PORT", "8080")
+	t.Setenv("IDX_SERVERS_1_HOST", "b.internal")
+	t.Setenv("IDX_SERVERS_5_HOST", "gap.internal")
+	t.Setenv("IDX_TAGS_0", "blue")
+
+	manager := NewManager()
+	manager.SetupEnvironmentOverrides()
+	manager.SetEnvPrefix("IDX")
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	var cfg Config
+	if err := manager.Unmarshal(&cfg); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if len(cfg.Servers) != 2 {
+		t.Fatalf("Expected 2 servers, gap index ignored, got %+v", cfg.Servers)
+	}
+	if cfg.Servers[0] != (Server{Host: "a.internal", Port: 8080}) {
+		t.Errorf("Expected port override on first server, got %+v", cfg.Servers[0])
+	}
+	if cfg.Servers[1].Host != "b.internal" {
+		t.Errorf("Expected appended server, got %+v", cfg.Servers[1])
+	}
+	if len(cfg.Tags) != 1 || cfg.Tags[0] != "blue" {
+		t.Errorf("Expected tags from env, got %v", cfg.Tags)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	load := func(input string) *Manager {
+		manager := NewManager()
+		manager.SetConfigType("yaml")
+		if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+			t.Fatalf("Failed to load yaml: %v", err)
+		}
+		return manager
+	}
+
+	current := load("server:\n  port: 8080\n  legacy: true\ndb:\n  password: old\n")
+	shipped := load("server:\n  port: 9090\n  timeout: 5s\ndb:\n  password: new\n")
+
+	changes := Diff(current, shipped)
+	expected := []ConfigChange{
+		{Key: "db.password", Kind: ChangeChanged, Old: "old", New: "new"},
+		{Key: "server.legacy", Kind: ChangeRemoved, Old: "true"},
+		{Key: "server.port", Kind: ChangeChanged, Old: "8080", New: "9090"},
+		{Key: "server.timeout", Kind: ChangeAdded, New: "5s"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected change %+v, got %+v", expected[i], changes[i])
+		}
+	}
+
+	formatted := FormatDiff(changes)
+	if strings.Contains(formatted, "old") || !strings.Contains(formatted, "~ server.port: 8080 -> 9090") {
+		t.Errorf("Unexpected formatted diff:\n%s", formatted)
+	}
+}
+
+func TestGetEnum(t *testing.T) {
+	manager := NewManager()
+	allowed := []string{"dev", "test", "prod"}
+
+	if got, err := manager.GetEnum("env", allowed, "dev"); err != nil || got != "dev" {
+		t.Errorf("Expected default 'dev', got %q (%v)", got, err)
+	}
+
+	manager.Set("env", " PROD ")
+	if got, err := manager.GetEnum("env", allowed, "dev"); err != nil || got != "prod" {
+		t.Errorf("Expected 'prod', got %q (%v)", got, err)
+	}
+
+	manager.Set("env", "prdo")
+	_, err := manager.GetEnum("env", allowed, "dev")
+	if err == nil || !strings.Contains(err.Error(), "dev, test, prod") {
+		t.Errorf("Expected error listing valid values, got %v", err)
+	}
+}
+
+func TestLoadFromURL(t *testing.T) {
+	up := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"server": {"port": "9090"}}`))
+	}))
+	defer server.Close()
+
+	cacheFile := t.TempDir() + "/config.cache"
+	manager := NewManager()
+	if err := manager.LoadFromURL(server.URL+"/app", WithBearerToken("secret"), WithCacheFile(cacheFile)); err != nil {
+		t.Fatalf("Failed to load config from URL: %v", err)
+	}
+	if got := manager.GetString("server.port"); got != "9090" {
+		t.Errorf("Expected server.port '9090', got %q", got)
+	}
+
+	// the config server is down, the cached copy is used
+	up = false
+	cached := NewManager()
+	if err := cached.LoadFromURL(server.URL+"/app", WithBearerToken("secret"), WithCacheFile(cacheFile)); err != nil {
+		t.Fatalf("Expected fallback to the cached copy, got %v", err)
+	}
+	if got := cached.GetString("server.port"); got != "9090" {
+		t.Errorf("Expected cached server.port '9090', got %q", got)
+	}
+
+	if err := NewManager().LoadFromURL(server.URL+"/app", WithoutCache()); err == nil {
+		t.Errorf("Expected an error without a cached copy")
+	}
+
+	// the format detected from the content type is cached along with the config
+	tomlUp := true
+	tomlServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tomlUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/toml")
+		w.Write([]byte("[server]\nport = \"9191\"\n"))
+	}))
+	defer tomlServer.Close()
+	tomlCache := t.TempDir() + "/toml.cache"
+	if err := NewManager().LoadFromURL(tomlServer.URL+"/app", WithCacheFile(tomlCache)); err != nil {
+		t.Fatalf("Failed to load toml config from URL: %v", err)
+	}
+	tomlUp = false
+	cachedTOML := NewManager()
+	if err := cachedTOML.LoadFromURL(tomlServer.URL+"/app", WithCacheFile(tomlCache)); err != nil {
+		t.Fatalf("Expected fallback to the cached toml copy, got %v", err)
+	}
+	if got := cachedTOML.GetString("server.port"); got != "9191" {
+		t.Errorf("Expected cached toml server.port '9191', got %q", got)
+	}
+
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("#"), maxURLConfigSize+1))
+	}))
+	defer large.Close()
+	if err := NewManager().LoadFromURL(large.URL, WithoutCache()); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Expected an error for a config over the size limit, got %v", err)
+	}
+}
+
+func TestLoadFromURLDoesNotBlockReads(t *testing.T) {
+	manager := NewManager()
+	manager.Set("server.port", "8080")
+
+	// the handler reads the config while the fetch is in flight
+	read := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := make(chan string, 1)
+		go func() { done <- manager.GetString("server.port") }()
+		select {
+		case port := <-done:
+			read <- port
+		case <-time.After(time.Second):
+			read <- "blocked"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"server": {"host": "remote.internal"}}`))
+	}))
+	defer server.Close()
+
+	if err := manager.LoadFromURL(server.URL, WithoutCache()); err != nil {
+		t.Fatalf("Failed to load config from URL: %v", err)
+	}
+	if got := <-read; got != "8080" {
+		t.Errorf("Expected reads to go on during the fetch, got %q", got)
+	}
+	if got := manager.GetString("server.host"); got != "remote.internal" {
+		t.Errorf("Expected server.host 'remote.internal', got %q", got)
+	}
+}
+
+func TestSetEnvPrefixes(t *testing.T) {
+	t.Setenv("OLDAPP_SERVER_PORT", "7070")
+	t.Setenv("OLDAPP_SERVER_HOST", "old.internal")
+	t.Setenv("NEWAPP_SERVER_HOST", "new.internal")
+
+	manager := NewManager()
+	manager.SetupEnvironmentOverrides()
+	manager.SetEnvPrefixes("NEWAPP", "OLDAPP")
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader("server:\n  port: 8080\n  host: file.internal\n")); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	if got := manager.GetString("server.port"); got != "7070" {
+		t.Errorf("Expected legacy prefix to override server.port, got %q", got)
+	}
+	if got := manager.GetString("server.host"); got != "new.internal" {
+		t.Errorf("Expected new prefix to take precedence for server.host, got %q", got)
+	}
+}
+
+func TestSetEnvPrefixesBindsKnownKeysOnly(t *testing.T) {
+	t.Setenv("OLDAPP_SERVER_PORT", "7070")
+	t.Setenv("OLDAPP_FEATURE_BETA", "true")
+
+	manager := NewManager()
+	manager.SetStrictEnv(true)
+	manager.SetupEnvironmentOverrides()
+	manager.SetEnvPrefixes("NEWAPP", "OLDAPP")
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader("server:\n  port: 8080\n")); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+	if got := manager.GetString("server.port"); got != "8080" {
+		t.Errorf("Expected strict env mode to ignore the prefixes, got %q", got)
+	}
+
+	manager = NewManager()
+	manager.SetupEnvironmentOverrides()
+	manager.SetEnvPrefixes("NEWAPP", "OLDAPP")
+	if _, ok := manager.Flatten()["feature.beta"]; ok {
+		t.Error("Expected an env var alone not to add a key")
+	}
+	if _, ok := manager.EnvBindings()["feature.beta"]; ok {
+		t.Error("Expected no binding for a key missing from the config")
+	}
+}
+
+func TestValidators(t *testing.T) {
+	manager := NewManager()
+	manager.Set("tls.enabled", "true")
+	manager.AddValidator(func(m *Manager) error {
+		if m.GetBool("tls.enabled") && m.GetString("tls.cert_file") == "" {
+			return errors.New("tls.cert_file is required when tls.enabled")
+		}
+		return nil
+	})
+	manager.AddValidator(func(m *Manager) error {
+		if m.GetString("server.port") == "" {
+			return errors.New("server.port is required")
+		}
+		return nil
+	})
+
+	err := manager.Validate()
+	if err == nil || !strings.Contains(err.Error(), "tls.cert_file") || !strings.Contains(err.Error(), "server.port") {
+		t.Errorf("Expected both validator errors, got %v", err)
+	}
+
+	manager.Set("tls.cert_file", "cert.pem")
+	manager.Set("server.port", "8080")
+	if err := manager.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+}
+
+func TestSlowLoadWarning(t *testing.T) {
+	path := t.TempDir() + "/config.yaml"
+	if err := os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	var out bytes.Buffer
+	manager := NewManager()
+	manager.log.Logger.SetOutput(&out)
+	defer manager.log.Logger.SetOutput(os.Stderr)
+
+	if manager.LastLoadDuration() != 0 {
+		t.Errorf("Expected no load duration before loading, got %s", manager.LastLoadDuration())
+	}
+
+	manager.SetSlowLoadThreshold(time.Nanosecond)
+	if err := manager.LoadFromFile(path); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if manager.LastLoadDuration() <= 0 {
+		t.Errorf("Expected a load duration, got %s", manager.LastLoadDuration())
+	}
+	if !strings.Contains(out.String(), "Slow config load") {
+		t.Errorf("Expected slow load warning, got %q", out.String())
+	}
+	// the threshold may change while a load is running
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		manager.SetSlowLoadThreshold(time.Hour)
+	}()
+	if err := manager.LoadFromFile(path); err != nil {
+		t.Fatalf("Failed to reload config: %v", err)
+	}
+	<-done
+}
+
+func TestWatch(t *testing.T) {
+	path := t.TempDir() + "/config.yaml"
+	if err := os.WriteFile(path, []byte("feature:\n  enabled: false\n  limit: 1\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	manager := NewManager()
+	if err := manager.LoadFromFile(path); err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	var auditBuf bytes.Buffer
+	manager.EnableAuditLog(&auditBuf)
+
+	changed := make(chan struct{}, 10)
+	if err := manager.Watch(func(e fsnotify.Event) {
+		changed <- struct{}{}
+	}); err != nil {
+		t.Fatalf("Failed to watch config: %v", err)
+	}
+	defer manager.StopWatching()
+
+	// readers keep going while the file is reloaded
+	stop := make(chan struct{})
+	readersDone := make(chan struct{})
+	go func() {
+		defer close(readersDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				manager.GetBool("feature.enabled")
+				manager.GetInt("feature.limit")
+				manager.Flatten()
+				manager.EnvForExec("APP")
+				manager.GetRawMap("feature")
+				manager.UnusedKeys([]string{"feature.enabled"})
+				manager.BindEnv("feature.name", "FEATURE_NAME")
+				manager.EnvBindings()
+			}
+		}
+	}()
+
+	// replace the file atomically, a truncating write may be reloaded half written
+	if err := os.WriteFile(path+".tmp", []byte("feature:\n  enabled: true\n  limit: 5\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write new config: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		t.Fatalf("Failed to replace config: %v", err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected change callback after rewriting the config file")
+	}
+	close(stop)
+	<-readersDone
+
+	if !manager.GetBool("feature.enabled") || manager.GetInt("feature.limit") != 5 {
+		t.Errorf("Expected reloaded values, got enabled=%v limit=%d",
+			manager.GetBool("feature.enabled"), manager.GetInt("feature.limit"))
+	}
+
+	audit := auditBuf.String()
+	for _, want := range []string{`"key":"feature.limit"`, `"old":"1"`, `"new":"5"`, `"source":"file reload"`} {
+		if !strings.Contains(audit, want) {
+			t.Errorf("Expected %s in the reload audit log, got %q", want, audit)
+		}
+	}
+}
+
+func TestWatchWithoutConfigFile(t *testing.T) {
+	manager := NewManager()
+	if err := manager.Watch(func(e fsnotify.Event) {}); err == nil {
+		t.Error("Expected error watching without a loaded config file")
+	}
+}
+
+func TestGetDurationFloatAndMap(t *testing.T) {
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	input := "server:\n  read_timeout: 30s\nlimiter:\n  rate: 2.5\nheaders:\n  X-Env: prod\n  X-Region: eu\n"
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+
+	if d := manager.GetDuration("server.read_timeout"); d != 30*time.Second {
+		t.Errorf("Expected 30s, got %s", d)
+	}
+	if f := manager.GetFloat64("limiter.rate"); f != 2.5 {
+		t.Errorf("Expected 2.5, got %v", f)
+	}
+	headers := manager.GetStringMapString("headers")
+	if len(headers) != 2 || headers["x-env"] != "prod" || headers["x-region"] != "eu" {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	path := t.TempDir() + "/.env"
+	content := `# local development
+DOTENV_PLAIN=plain value # comment
+export DOTENV_EXPORTED=yes
+
+DOTENV_URL=postgres://u:p@db/app?sslmode=disable&x=1
+DOTENV_DOUBLE="two\nlines # not a comment"
+DOTENV_SINGLE='raw \n value'
+DOTENV_PRESET=from-file
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+	for _, key := range []string{"DOTENV_PLAIN", "DOTENV_EXPORTED", "DOTENV_URL", "DOTENV_DOUBLE", "DOTENV_SINGLE"} {
+		defer os.Unsetenv(key)
+	}
+	t.Setenv("DOTENV_PRESET", "from-env")
+
+	manager := NewManager()
+	if err := manager.LoadDotEnv(path); err != nil {
+		t.Fatalf("Failed to load .env: %v", err)
+	}
+
+	expected := map[string]string{
+		"DOTENV_PLAIN":    "plain value",
+		"DOTENV_EXPORTED": "yes",
+		"DOTENV_URL":      "postgres://u:p@db/app?sslmode=disable&x=1",
+		"DOTENV_DOUBLE":   "two\nlines # not a comment",
+		"DOTENV_SINGLE":   `raw \n value`,
+		"DOTENV_PRESET":   "from-env",
+	}
+	for key, want := range expected {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("NOT A LINE\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+	if err := manager.LoadDotEnv(path); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("Expected error with line number, got %v", err)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var out bytes.Buffer
+	manager := NewManager()
+	logger := manager.log.Logger
+	logger.SetOutput(&out)
+	level := logger.GetLevel()
+	logger.SetLevel(logrus.DebugLevel)
+	defer func() {
+		logger.SetOutput(os.Stderr)
+		logger.SetLevel(level)
+	}()
+
+	manager.SetConfigType("yaml")
+	if err := manager.LoadFromReader(strings.NewReader("server:\n  port: 8080\ndb:\n  password: hunter2\n")); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+	manager.SetupEnvironmentOverrides()
+	t.Setenv("SERVER_HOST", "example.com")
+
+	manager.GetString("server.port")
+	if out.Len() != 0 {
+		t.Fatalf("Expected no access log before enabling, got %q", out.String())
+	}
+
+	manager.EnableAccessLog()
+	manager.GetInt("server.port")
+	manager.GetString("server.host")
+	manager.GetString("db.password")
+	manager.Set("feature.enabled", "true")
+	manager.GetBool("feature.enabled")
+	manager.GetString("missing")
+
+	logged := out.String()
+	for _, want := range []string{
+		"key=server.port module=config source=file value=8080",
+		"key=server.host module=config source=\"env:SERVER_HOST\" value=example.com",
+		"key=db.password module=config source=file value=\"******\"",
+		"key=feature.enabled module=config source=set value=true",
+		"key=missing module=config source=unset",
+	} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Expected access log to contain %q, got:\n%s", want, logged)
+		}
+	}
+	if strings.Contains(logged, "hunter2") {
+		t.Error("Expected sensitive value to be redacted")
+	}
+}
+
+func TestRequireKeys(t *testing.T) {
+	manager := NewManager()
+	manager.SetConfigType("yaml")
+	input := "server:\n  port: 8080\n  host: \"\"\ndatabase:\n  replicas: []\n"
+	if err := manager.LoadFromReader(strings.NewReader(input)); err != nil {
+		t.Fatalf("Failed to load yaml: %v", err)
+	}
+	manager.BindEnv("database.url", "REQUIRE_TEST_DATABASE_URL")
+	t.Setenv("REQUIRE_TEST_DATABASE_URL", "postgres://db/app")
+
+	if err := manager.RequireKeys("server.port", "database.url"); err != nil {
+		t.Errorf("Expected file and env keys to be present, got %v", err)
+	}
+
+	err := manager.RequireKeys("server.port", "server.host", "database.replicas", "redis.addr")
+	var missingErr *MissingKeysError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("Expected MissingKeysError, got %v", err)
+	}
+	expected := []string{"server.host", "database.replicas", "redis.addr"}
+	if !reflect.DeepEqual(missingErr.Keys, expected) {
+		t.Errorf("Expected missing keys %v, got %v", expected, missingErr.Keys)
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind is the kind of a config change
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// ConfigChange is a difference of a single dotted key between two configs
+type ConfigChange struct {
+	Key  string
+	Kind ChangeKind
+	// Old is empty for added keys, New for removed keys
+	Old string
+	New string
+}
+
+// Diff compares the resolved configs of a and b, e.g. a customized config against a newly
+// shipped default, and returns the changes from a to b sorted by key. Lists compare as a whole.
+func Diff(a, b *Manager) []ConfigChange {
+	return diffFlat(a.Flatten(), b.Flatten())
+}
+
+// diffFlat returns the changes between two flattened configs sorted by key
+func diffFlat(before, after map[string]string) []ConfigChange {
+	var changes []ConfigChange
+	for key, oldValue := range before {
+		newValue, ok := after[key]
+		switch {
+		case !ok:
+			changes = append(changes, ConfigChange{Key: key, Kind: ChangeRemoved, Old: oldValue})
+		case newValue != oldValue:
+			changes = append(changes, ConfigChange{Key: key, Kind: ChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, ConfigChange{Key: key, Kind: ChangeAdded, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// FormatDiff renders changes one per line, prefixed with + (added), - (removed) or ~ (changed).
+// Values of sensitive keys are redacted.
+func FormatDiff(changes []ConfigChange) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		oldValue, newValue := change.Old, change.New
+		if IsSensitiveKey(change.Key) {
+			oldValue, newValue = RedactedValue, RedactedValue
+		}
+		switch change.Kind {
+		case ChangeAdded:
+			fmt.Fprintf(&sb, "+ %s: %s\n", change.Key, newValue)
+		case ChangeRemoved:
+			fmt.Fprintf(&sb, "- %s: %s\n", change.Key, oldValue)
+		case ChangeChanged:
+			fmt.Fprintf(&sb, "~ %s: %s -> %s\n", change.Key, oldValue, newValue)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadDotEnv sets the env vars listed in a .env file, so the env overrides pick them up.
+// Lines are KEY=VALUE, optionally prefixed by `export`; blank lines and lines starting with #
+// are ignored. Values may be double quoted (with \n, \" and \\ escapes), single quoted (literal)
+// or unquoted, where a " #" starts a comment. Values may contain '='. Variables already set in
+// the environment are not overwritten. Call it before SetupEnvironmentOverrides.
+func (m *Manager) LoadDotEnv(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	var order []string
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := parseDotEnvLine(line)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		if _, seen := vars[key]; !seen {
+			order = append(order, key)
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	set := 0
+	for _, key := range order {
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, vars[key]); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+		set++
+	}
+	m.log.Infof("Loaded %d env vars from %s (%d already set)", set, path, len(order)-set)
+	return nil
+}
+
+// parseDotEnvLine parses a non-blank, non-comment .env line into its key and value
+func parseDotEnvLine(line string) (string, string, error) {
+	line = strings.TrimPrefix(line, "export ")
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", fmt.Errorf("missing '=' in %q", line)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted value of %s", key)
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid quoted value of %s: %w", key, err)
+		}
+		return key, unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted value of %s", key)
+		}
+		return key, value[1 : end+1], nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return key, value, nil
+	}
+}
+
+// closingQuote returns the index of the unescaped double quote closing the value starting
+// with a double quote, or -1
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// indexedEnvVar is an env var overriding an element, or a field of an element, of a list
+type indexedEnvVar struct {
+	name  string
+	index int
+	field string
+	value string
+}
+
+// applyIndexedEnv overrides list elements in settings from env vars with array indices.
+// For a list at servers with env prefix APP, APP_SERVERS_0 replaces the first element and
+// APP_SERVERS_0_HOST sets the host field of the first element. Fields are matched as one
+// key (APP_SERVERS_0_MAX_CONNS sets max_conns), deeper nesting inside elements is not supported.
+// Only lists present in the config file or defaults are considered, declare `servers: []` to
+// allow a list to come from env vars only. An index equal to the list length appends an element,
+// larger indices would leave a gap and are ignored with a warning.
+// settings is modified in place, lists and element maps are copied before they are changed.
+func (m *Manager) applyIndexedEnv(settings map[string]interface{}) {
+	if m.strictEnv || !m.automaticEnv {
+		return
+	}
+
+	environ := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			environ[name] = value
+		}
+	}
+	m.applyIndexedEnvAt(settings, "", environ)
+}
+
+func (m *Manager) applyIndexedEnvAt(node map[string]interface{}, path string, environ map[string]string) {
+	for key, value := range node {
+		keyPath := joinKey(path, key)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			m.applyIndexedEnvAt(v, keyPath, environ)
+		case []interface{}:
+			node[key] = m.indexedEnvList(keyPath, v, environ)
+		}
+	}
+}
+
+// indexedEnvList returns list with the env var overrides of its elements applied
+func (m *Manager) indexedEnvList(path string, list []interface{}, environ map[string]string) []interface{} {
+	base := EnvVarName(m.viper.GetEnvPrefix(), path) + "_"
+
+	var vars []indexedEnvVar
+	for name, value := range environ {
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		indexStr, field, _ := strings.Cut(name[len(base):], "_")
+		index, err := strconv.Atoi(indexStr)
+		if err != nil || index < 0 {
+			continue
+		}
+		vars = append(vars, indexedEnvVar{name: name, index: index, field: strings.ToLower(field), value: value})
+	}
+	if len(vars) == 0 {
+		return list
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		if vars[i].index != vars[j].index {
+			return vars[i].index < vars[j].index
+		}
+		return vars[i].field < vars[j].field
+	})
+
+	out := append([]interface{}{}, list...)
+	copied := make(map[int]bool)
+	for _, v := range vars {
+		switch {
+		case v.index > len(out):
+			m.log.Warnf("Ignoring env %s: index %d leaves a gap in %s of length %d", v.name, v.index, path, len(out))
+			continue
+		case v.index == len(out):
+			out = append(out, nil)
+		}
+
+		if v.field == "" {
+			out[v.index] = v.value
+			continue
+		}
+
+		element, ok := out[v.index].(map[string]interface{})
+		if !ok && out[v.index] != nil {
+			m.log.Warnf("Ignoring env %s: element %d of %s is not an object", v.name, v.index, path)
+			continue
+		}
+		if !copied[v.index] {
+			element = mergeMaps(nil, element)
+			copied[v.index] = true
+		}
+		element[v.field] = v.value
+		out[v.index] = element
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package config
+
+import "strings"
+
+// SetEnvPrefixes makes every key resolve from env vars with any of the prefixes, the first set
+// one wins. It eases migrating env var conventions: SetEnvPrefixes("NEW", "OLD") honors
+// OLD_SERVER_PORT from legacy deployment manifests while NEW_SERVER_PORT takes precedence.
+// The first prefix is also used for automatic env lookup like SetEnvPrefix, explicit
+// bindings of a key (BindEnv, BindEnvPrefix) take precedence over the prefixes. Only keys
+// known from the config file or defaults are bound to the prefixed env vars, refreshed when a
+// file is loaded, so an env var alone never adds a key. In strict env mode the prefixes are not
+// bound at all, since only explicit bindings are honored there.
+func (m *Manager) SetEnvPrefixes(prefixes ...string) {
+	if len(prefixes) == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.strictEnv {
+		m.log.Warn("Strict env mode is enabled, env prefixes are only used by explicit bindings")
+	}
+
+	m.envPrefixes = prefixes
+	if m.prefixBindings == nil {
+		m.prefixBindings = make(map[string]bool)
+	}
+	m.setEnvPrefix(prefixes[0])
+	m.applyEnvBindings()
+}
+
+// applyEnvPrefixes binds the known keys to the env vars of all prefixes
+func (m *Manager) applyEnvPrefixes() {
+	if len(m.envPrefixes) < 2 || m.strictEnv {
+		return
+	}
+
+	for _, key := range m.viper.AllKeys() {
+		// explicit bindings (BindEnv, BindEnvPrefix) win
+		if _, bound := m.envBindings[key]; bound && !m.prefixBindings[key] {
+			continue
+		}
+		names := make([]string, len(m.envPrefixes))
+		for i, prefix := range m.envPrefixes {
+			names[i] = EnvVarName(prefix, key)
+		}
+		m.bindEnvNames(key, names...)
+		m.prefixBindings[key] = true
+	}
+}
+
+// bindEnvNames binds key to several env vars, the first set one wins
+func (m *Manager) bindEnvNames(key string, envVars ...string) {
+	if m.envBindings == nil {
+		m.envBindings = make(map[string]string)
+	}
+	m.envBindings[strings.ToLower(key)] = strings.Join(envVars, ",")
+	m.viper.BindEnv(append([]string{key}, envVars...)...)
+}
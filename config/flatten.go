@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Flatten returns the resolved configuration as dotted keys mapped to string values.
+// Lists are joined with commas, nulls become empty strings.
+func (m *Manager) Flatten() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.flatten()
+}
+
+// flatten is Flatten, the caller must hold the lock
+func (m *Manager) flatten() map[string]string {
+	flat := make(map[string]string)
+	flattenInto(flat, "", m.viper.AllSettings())
+	return flat
+}
+
+// ExportEnv returns the resolved configuration as env vars, e.g. server.port becomes
+// APP_SERVER_PORT with prefix "APP". An empty prefix omits it.
+func (m *Manager) ExportEnv(prefix string) map[string]string {
+	env := make(map[string]string)
+	for key, value := range m.Flatten() {
+		env[EnvVarName(prefix, key)] = value
+	}
+	return env
+}
+
+// EnvForExec returns the resolved configuration as sorted KEY=value entries named like
+// ExportEnv, ready for exec.Cmd.Env, so a child process gets the merged config without
+// re-reading files. Nothing is redacted: secrets end up in the child's environment, where
+// they are visible to anything it spawns and, on most systems, to the process owner via /proc.
+// Filter the result if the child must not see them. Append os.Environ() to keep PATH etc.
+func (m *Manager) EnvForExec(prefix string) []string {
+	env := m.ExportEnv(prefix)
+	entries := make([]string, 0, len(env))
+	for name, value := range env {
+		entries = append(entries, name+"="+value)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// EnvVarName returns the env var name of a config key following the prefix + underscore convention
+func EnvVarName(prefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+func flattenInto(flat map[string]string, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenInto(flat, joinKey(prefix, key), child)
+		}
+	case map[interface{}]interface{}:
+		for key, child := range v {
+			flattenInto(flat, joinKey(prefix, fmt.Sprint(key)), child)
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, cast.ToString(item))
+		}
+		flat[prefix] = strings.Join(items, ",")
+	case []string:
+		flat[prefix] = strings.Join(v, ",")
+	case nil:
+		flat[prefix] = ""
+	default:
+		s, err := cast.ToStringE(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		}
+		flat[prefix] = s
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
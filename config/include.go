@@ -0,0 +1,156 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// IncludeKey lists other config files merged into the including one, resolved relative to it.
+// Keys of the including file take precedence over included ones, later includes over earlier ones.
+const IncludeKey = "include"
+
+// includedFile is a config file merged with everything it includes
+type includedFile struct {
+	settings map[string]interface{}
+	raw      map[string]interface{}
+}
+
+// applyIncludes merges the files included by the loaded configFile into the config.
+// Includes are read from the file itself, never from env vars, Set values or defaults.
+func (m *Manager) applyIncludes(configFile string) error {
+	if !m.viper.InConfig(IncludeKey) {
+		return nil
+	}
+	path, err := filepath.Abs(configFile)
+	if err != nil {
+		return err
+	}
+	// the file's own settings, viper's AllSettings would also copy env vars, Set values and
+	// defaults into the config file layer
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// like LoadFromFile, SetConfigType wins over the extension of the main file
+	configType := m.configType
+	if configType == "" {
+		configType = strings.ToLower(configTypeOf(path))
+	}
+	own, err := m.parseConfigFile(configType, data)
+	if err != nil {
+		return err
+	}
+
+	includes := cast.ToStringSlice(own.settings[IncludeKey])
+	if len(includes) == 0 {
+		return nil
+	}
+	merged, err := m.resolveIncludes(path, includes, []string{path})
+	if err != nil {
+		return err
+	}
+
+	// merging the file's own values over its includes keeps them on top
+	m.raw = mergeMaps(merged.raw, m.raw)
+	if err := m.viper.MergeConfigMap(mergeMaps(merged.settings, own.settings)); err != nil {
+		return err
+	}
+	m.applyEnvBindings()
+	return nil
+}
+
+// resolveIncludes loads and merges the includes of the file at path.
+// stack holds the chain of including files to detect circular includes.
+func (m *Manager) resolveIncludes(path string, includes []string, stack []string) (*includedFile, error) {
+	merged := &includedFile{settings: map[string]interface{}{}, raw: map[string]interface{}{}}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		include = filepath.Clean(include)
+
+		for _, p := range stack {
+			if p == include {
+				return nil, fmt.Errorf("circular config include: %s -> %s", strings.Join(stack, " -> "), include)
+			}
+		}
+
+		file, err := m.readIncludedFile(include, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged.settings = mergeMaps(merged.settings, file.settings)
+		merged.raw = mergeMaps(merged.raw, file.raw)
+		m.log.Infof("Included config file: %s", include)
+	}
+	return merged, nil
+}
+
+// readIncludedFile reads path and recursively merges its own includes below it
+func (m *Manager) readIncludedFile(path string, stack []string) (*includedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read included config %s: %w", path, err)
+	}
+	// included files are parsed by extension, falling back to SetConfigType
+	configType := strings.ToLower(configTypeOf(path))
+	if configType == "" {
+		configType = m.configType
+	}
+	file, err := m.parseConfigFile(configType, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse included config %s: %w", path, err)
+	}
+
+	includes := cast.ToStringSlice(file.settings[IncludeKey])
+	if len(includes) == 0 {
+		return file, nil
+	}
+	nested, err := m.resolveIncludes(path, includes, append(stack[:len(stack):len(stack)], path))
+	if err != nil {
+		return nil, err
+	}
+	return &includedFile{
+		settings: mergeMaps(nested.settings, file.settings),
+		raw:      mergeMaps(nested.raw, file.raw),
+	}, nil
+}
+
+// parseConfigFile parses data in configType on its own, without includes
+func (m *Manager) parseConfigFile(configType string, data []byte) (*includedFile, error) {
+	viperType, viperData := configType, data
+	if configType == ConfigTypeJSONC {
+		viperType, viperData = "json", stripJSONC(data)
+	}
+
+	v := viper.New()
+	v.SetConfigType(viperType)
+	if err := v.ReadConfig(bytes.NewReader(viperData)); err != nil {
+		return nil, err
+	}
+	return &includedFile{settings: v.AllSettings(), raw: parseRaw(data, configType)}, nil
+}
+
+// mergeMaps deep merges src into dst, values of src win. Nested maps are merged, everything
+// else is replaced. dst is modified and returned, a nil dst is allocated.
+func mergeMaps(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+	for key, value := range src {
+		srcMap, srcOK := value.(map[string]interface{})
+		dstMap, dstOK := dst[key].(map[string]interface{})
+		if srcOK && dstOK {
+			dst[key] = mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+	return dst
+}
@@ -0,0 +1,39 @@
+package config
+
+import "time"
+
+// DefaultSlowLoadThreshold is the config load duration above which a warning is logged
+const DefaultSlowLoadThreshold = time.Second
+
+// SetSlowLoadThreshold sets the LoadFromFile duration above which a warning is logged,
+// e.g. to spot large files on slow storage. Zero restores DefaultSlowLoadThreshold.
+func (m *Manager) SetSlowLoadThreshold(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.slowLoadThreshold = d
+}
+
+// LastLoadDuration returns how long the last LoadFromFile took, including includes and
+// remote fetches, or zero if nothing was loaded yet
+func (m *Manager) LastLoadDuration() time.Duration {
+	return time.Duration(m.lastLoadDuration.Load())
+}
+
+// timeLoad records the duration of a load of configFile started at start, the caller must
+// not hold the lock
+func (m *Manager) timeLoad(configFile string, start time.Time) {
+	took := time.Since(start)
+	m.lastLoadDuration.Store(int64(took))
+
+	m.mu.RLock()
+	threshold := m.slowLoadThreshold
+	m.mu.RUnlock()
+	if threshold <= 0 {
+		threshold = DefaultSlowLoadThreshold
+	}
+	if took > threshold {
+		m.log.Warnf("Slow config load: %s took %s (threshold %s)", configFile, took.Round(time.Millisecond), threshold)
+		return
+	}
+	m.log.Debugf("Config load of %s took %s", configFile, took)
+}
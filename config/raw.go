@@ -0,0 +1,71 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseRaw decodes config data without viper's key normalization.
+// Only yaml and json (including jsonc) are supported, other formats return nil.
+func parseRaw(data []byte, configType string) map[string]interface{} {
+	switch strings.ToLower(configType) {
+	case ConfigTypeJSONC:
+		data = stripJSONC(data)
+	case "yaml", "yml", "json":
+	default:
+		return nil
+	}
+
+	// JSON is a subset of YAML, so one decoder handles both
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	return raw
+}
+
+// configTypeOf returns the config type of a file from its extension
+func configTypeOf(configFile string) string {
+	return strings.TrimPrefix(filepath.Ext(configFile), ".")
+}
+
+// GetRawMap returns the map at key as it appears in the loaded config file, with the original
+// key casing preserved (viper lowercases all keys). Path segments of key are matched
+// case-insensitively. Values from Set, env vars or defaults are not included.
+// Returns nil if the key is absent, not a map, or the file format is not yaml/json.
+func (m *Manager) GetRawMap(key string) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var current interface{} = m.raw
+	for _, part := range strings.Split(key, ".") {
+		node, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = lookupFold(node, part)
+	}
+	node, _ := current.(map[string]interface{})
+	return node
+}
+
+// lookupFold returns node[key], falling back to a case-insensitive match
+func lookupFold(node map[string]interface{}, key string) interface{} {
+	v, _ := lookupFoldOK(node, key)
+	return v
+}
+
+// lookupFoldOK is lookupFold also reporting whether the key exists
+func lookupFoldOK(node map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := node[key]; ok {
+		return v, true
+	}
+	for k, v := range node {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// MissingKeysError lists the required keys that are unset or empty
+type MissingKeysError struct {
+	Keys []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return "missing required config keys: " + strings.Join(e.Keys, ", ")
+}
+
+// RequireKeys returns a *MissingKeysError listing every key that is unset or empty, taking the
+// config file, env vars and Set into account. Empty strings, lists and maps count as missing.
+func (m *Manager) RequireKeys(keys ...string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var missing []string
+	for _, key := range keys {
+		if isEmptyValue(m.viper.Get(key)) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingKeysError{Keys: missing}
+	}
+	return nil
+}
+
+// isEmptyValue reports whether a config value is absent or empty
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s) == ""
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// BindStructEnv binds an env var to every field of the struct v, keyed by its mapstructure tag
+// under prefix. Env var names are the config keys upper-cased with dots replaced by underscores.
+// Nested structs are supported. Example: BindStructEnv("server", &ServerConfig{}) binds
+// server.port to SERVER_PORT and server.tls.cert_file to SERVER_TLS_CERT_FILE.
+func (m *Manager) BindStructEnv(prefix string, v interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range structKeys(prefix, reflect.TypeOf(v)) {
+		m.bindEnv(key, strings.ToUpper(strings.ReplaceAll(key, ".", "_")))
+	}
+}
+
+// structKeys returns the dotted config keys of all leaf fields of t under prefix
+func structKeys(prefix string, t reflect.Type) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("mapstructure")
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		// squashed embedded structs share the parent prefix
+		if hasTagOption(parts[1:], "squash") || (field.Anonymous && name == "") {
+			keys = append(keys, structKeys(prefix, fieldType)...)
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+			keys = append(keys, structKeys(key, fieldType)...)
+			continue
+		}
+		keys = append(keys, strings.ToLower(key))
+	}
+	return keys
+}
+
+func hasTagOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// settingsAt returns the resolved value at key. Unlike viper.Get, nested keys overridden
+// by env vars or Set are merged with the values from the config file, and list elements
+// are overridden by indexed env vars (see applyIndexedEnv).
+func (m *Manager) settingsAt(key string) interface{} {
+	settings := m.viper.AllSettings()
+	m.applyIndexedEnv(settings)
+
+	var current interface{} = settings
+	if key == "" {
+		return current
+	}
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		node, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = node[part]
+	}
+	return current
+}
+
+// decode decodes input into output with the same settings viper uses
+func decode(input interface{}, output interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           output,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsClientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLSConfig builds a validated *tls.Config from the keys under prefix:
+//
+//	<prefix>.cert_file            certificate PEM file, requires key_file
+//	<prefix>.key_file             private key PEM file, requires cert_file
+//	<prefix>.ca_file              CA bundle used to verify peers (client certs on servers)
+//	<prefix>.min_version          1.0, 1.1, 1.2 (default) or 1.3
+//	<prefix>.client_auth          none (default), request, require, verify_if_given, require_and_verify
+//	<prefix>.cipher_suites        list of cipher suite names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+//	<prefix>.server_name          expected server name, for clients
+//	<prefix>.insecure_skip_verify skip peer verification, for clients in tests only
+func TLSConfig(m *Manager, prefix string) (*tls.Config, error) {
+	key := func(name string) string {
+		return prefix + "." + name
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         m.GetString(key("server_name")),
+		InsecureSkipVerify: m.GetBool(key("insecure_skip_verify")),
+	}
+
+	certFile := m.GetString(key("cert_file"))
+	keyFile := m.GetString(key("key_file"))
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("%s and %s must be set together", key("cert_file"), key("key_file"))
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", key("cert_file"), err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile := m.GetString(key("ca_file")); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", key("ca_file"), err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s contains no valid certificates", key("ca_file"))
+		}
+		// The CA verifies servers for clients and client certificates for servers
+		tlsConfig.RootCAs = pool
+		tlsConfig.ClientCAs = pool
+	}
+
+	if minVersion := m.GetString(key("min_version")); minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid %s %q, valid values: 1.0, 1.1, 1.2, 1.3", key("min_version"), minVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if clientAuth := m.GetString(key("client_auth")); clientAuth != "" {
+		authType, ok := tlsClientAuthTypes[strings.ToLower(clientAuth)]
+		if !ok {
+			return nil, fmt.Errorf("invalid %s %q, valid values: none, request, require, verify_if_given, require_and_verify", key("client_auth"), clientAuth)
+		}
+		if authType >= tls.VerifyClientCertIfGiven && tlsConfig.ClientCAs == nil {
+			return nil, fmt.Errorf("%s %q requires %s", key("client_auth"), clientAuth, key("ca_file"))
+		}
+		tlsConfig.ClientAuth = authType
+	}
+
+	if names := m.GetStringSlice(key("cipher_suites")); len(names) > 0 {
+		suites, err := cipherSuites(names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key("cipher_suites"), err)
+		}
+		tlsConfig.CipherSuites = suites
+	}
+
+	return tlsConfig, nil
+}
+
+// cipherSuites maps cipher suite names to their ids, insecure suites are rejected
+func cipherSuites(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
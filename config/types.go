@@ -0,0 +1,74 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Get returns the value of key with its type preserved, e.g. int for `port: 8080` and string
+// for `port: "8080"`. Values from env vars are always strings. Returns nil for absent and null keys.
+func (m *Manager) Get(key string) interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value := m.viper.Get(key)
+	m.logAccess(key, value)
+	return value
+}
+
+// IsNull reports whether key is explicitly null in the config file (`key: null` or `key: ~`),
+// as opposed to absent. A non-null override from Set or an env var makes it non-null.
+func (m *Manager) IsNull(key string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.viper.Get(key) != nil {
+		return false
+	}
+	if m.raw != nil {
+		return rawHasKey(m.raw, key)
+	}
+	// formats without a raw copy: viper lists null keys of the file, but also unset env bindings
+	_, bound := m.envBindings[strings.ToLower(key)]
+	if bound {
+		return false
+	}
+	for _, k := range m.viper.AllKeys() {
+		if k == strings.ToLower(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kind returns the kind of the value of key, reflect.Invalid for absent and null keys.
+// Nested objects are reflect.Map and lists reflect.Slice.
+func (m *Manager) Kind(key string) reflect.Kind {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value := m.viper.Get(key)
+	if value == nil {
+		return reflect.Invalid
+	}
+	return reflect.TypeOf(value).Kind()
+}
+
+// rawHasKey reports whether the dotted key exists in raw, matching segments case-insensitively
+func rawHasKey(raw map[string]interface{}, key string) bool {
+	parts := strings.Split(key, ".")
+	node := raw
+	for i, part := range parts {
+		value, ok := lookupFoldOK(node, part)
+		if !ok {
+			return false
+		}
+		if i == len(parts)-1 {
+			return true
+		}
+		if node, ok = value.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return false
+}
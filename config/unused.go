@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// StructKeys returns the dotted config keys of all fields of the struct v under prefix,
+// following its mapstructure tags like BindStructEnv
+func StructKeys(prefix string, v interface{}) []string {
+	return structKeys(prefix, reflect.TypeOf(v))
+}
+
+// UnusedKeys returns the keys of the loaded config file that are not covered by knownKeys,
+// sorted. A known key also covers everything below it, so "headers" covers "headers.x-api-key".
+// It catches stale keys left behind after a feature was removed and typos.
+func (m *Manager) UnusedKeys(knownKeys []string) []string {
+	known := make(map[string]bool, len(knownKeys))
+	for _, key := range knownKeys {
+		known[strings.ToLower(key)] = true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var unused []string
+	for _, key := range m.viper.AllKeys() {
+		if !m.viper.InConfig(key) || isKnownKey(key, known) {
+			continue
+		}
+		unused = append(unused, key)
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// isKnownKey reports whether key or one of its parents is known
+func isKnownKey(key string, known map[string]bool) bool {
+	for {
+		if known[key] {
+			return true
+		}
+		idx := strings.LastIndex(key, ".")
+		if idx < 0 {
+			return false
+		}
+		key = key[:idx]
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxURLConfigSize bounds the size of a config fetched by LoadFromURL
+const maxURLConfigSize = 10 << 20
+
+// BearerTokenEnv is the env var holding the bearer token used when LoadFromFile gets a URL
+const BearerTokenEnv = "CONFIG_BEARER_TOKEN"
+
+// URLOption configures LoadFromURL
+type URLOption func(*urlOptions)
+
+type urlOptions struct {
+	timeout     time.Duration
+	bearerToken string
+	format      string
+	cacheFile   string
+	noCache     bool
+}
+
+// WithFetchTimeout bounds the whole request, default 10s
+func WithFetchTimeout(d time.Duration) URLOption {
+	return func(o *urlOptions) {
+		o.timeout = d
+	}
+}
+
+// WithBearerToken sends an Authorization: Bearer header
+func WithBearerToken(token string) URLOption {
+	return func(o *urlOptions) {
+		o.bearerToken = token
+	}
+}
+
+// WithFormat forces the config format instead of detecting it from the content type
+func WithFormat(format string) URLOption {
+	return func(o *urlOptions) {
+		o.format = strings.ToLower(format)
+	}
+}
+
+// WithCacheFile sets the path of the local copy, by default a file named after the URL hash
+// in the user cache dir (e.g. ~/.cache/quick-config on Linux)
+func WithCacheFile(path string) URLOption {
+	return func(o *urlOptions) {
+		o.cacheFile = path
+	}
+}
+
+// WithoutCache disables reading and writing the local copy
+func WithoutCache() URLOption {
+	return func(o *urlOptions) {
+		o.noCache = true
+	}
+}
+
+// isURL reports whether configFile is an http or https URL
+func isURL(configFile string) bool {
+	return strings.HasPrefix(configFile, "http://") || strings.HasPrefix(configFile, "https://")
+}
+
+// LoadFromURL fetches the config from an http or https URL, e.g. a config server.
+// The format is taken from WithFormat, SetConfigType, the response content type or the URL
+// extension, in that order, and defaults to yaml. Configs over 10MiB are rejected. Files listed
+// under IncludeKey are not merged for URL configs. Every successful fetch is stored in a local
+// cache file with its format; if a later fetch fails the cached copy is loaded with a warning,
+// so a config server outage does not prevent startup. The cache holds the config in plain text, protect
+// its directory like the config itself. TLS certificates are verified as usual, and the bearer
+// token is sent over plain http too, so only use it with https. Reads are not blocked while
+// the config is fetched, only while it is merged.
+func (m *Manager) LoadFromURL(rawURL string, opts ...URLOption) error {
+	data, configType, err := m.fetchURL(rawURL, opts...)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mergeURL(rawURL, data, configType)
+}
+
+// fetchURL fetches the config of LoadFromURL, falling back to the cached copy. It does not
+// hold the lock, so reads are not blocked by a slow config server.
+func (m *Manager) fetchURL(rawURL string, opts ...URLOption) ([]byte, string, error) {
+	options := &urlOptions{timeout: 10 * time.Second}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.format == "" {
+		m.mu.RLock()
+		options.format = m.configType
+		m.mu.RUnlock()
+	}
+	if !options.noCache && options.cacheFile == "" {
+		options.cacheFile = defaultCacheFile(rawURL)
+	}
+
+	data, configType, err := fetchConfig(rawURL, options)
+	if err != nil {
+		if options.noCache || options.cacheFile == "" {
+			return nil, "", err
+		}
+		cached, cacheErr := os.ReadFile(options.cacheFile)
+		if cacheErr != nil {
+			return nil, "", fmt.Errorf("%w (no cached copy: %v)", err, cacheErr)
+		}
+		m.log.Warnf("Failed to fetch config from %s, using cached copy %s: %v", redactURL(rawURL), options.cacheFile, err)
+		return cached, cachedConfigType(rawURL, options), nil
+	}
+
+	if options.cacheFile != "" {
+		err := writeCacheFile(options.cacheFile, data)
+		if err == nil {
+			err = writeCacheFile(options.cacheFile+cacheTypeSuffix, []byte(configType))
+		}
+		if err != nil {
+			m.log.Warnf("Failed to cache config from %s: %v", redactURL(rawURL), err)
+		}
+	}
+	return data, configType, nil
+}
+
+// mergeURL loads a config fetched by fetchURL, the caller must hold the lock
+func (m *Manager) mergeURL(rawURL string, data []byte, configType string) error {
+	if err := m.loadReader(bytes.NewReader(data), configType); err != nil {
+		return err
+	}
+	if m.viper.InConfig(IncludeKey) {
+		m.log.Warnf("Config from URL %s lists includes, they are not merged for URL configs", redactURL(rawURL))
+	}
+	m.log.Infof("Loaded config from URL: %s", redactURL(rawURL))
+	return nil
+}
+
+// fetchConfig downloads the config and detects its format
+func fetchConfig(rawURL string, options *urlOptions) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if options.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+options.bearerToken)
+	}
+
+	client := &http.Client{Timeout: options.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetch config: unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxURLConfigSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch config: %w", err)
+	}
+	if len(data) > maxURLConfigSize {
+		return nil, "", fmt.Errorf("fetch config: larger than %d bytes", maxURLConfigSize)
+	}
+	return data, urlConfigType(rawURL, options, resp.Header.Get("Content-Type")), nil
+}
+
+// urlConfigType picks the config format of a fetched config
+func urlConfigType(rawURL string, options *urlOptions, contentType string) string {
+	if options.format != "" {
+		return options.format
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		return "json"
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return "yaml"
+	case "application/toml":
+		return "toml"
+	}
+
+	if u, err := url.Parse(rawURL); err == nil {
+		if ext := strings.TrimPrefix(path.Ext(u.Path), "."); ext != "" {
+			return strings.ToLower(ext)
+		}
+	}
+	return "yaml"
+}
+
+// cacheTypeSuffix names the file next to the cache file holding the format of the cached config
+const cacheTypeSuffix = ".type"
+
+// cachedConfigType returns the format stored with the cached config, detecting it from the
+// URL for caches written before the format was stored
+func cachedConfigType(rawURL string, options *urlOptions) string {
+	if options.format == "" {
+		if configType, err := os.ReadFile(options.cacheFile + cacheTypeSuffix); err == nil && len(configType) > 0 {
+			return string(configType)
+		}
+	}
+	return urlConfigType(rawURL, options, "")
+}
+
+// defaultCacheFile returns the cache file of rawURL in the user cache dir, or "" without one
+func defaultCacheFile(rawURL string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, "quick-config", hex.EncodeToString(sum[:16]))
+}
+
+// writeCacheFile replaces the cache file atomically, readable by the owner only
+func writeCacheFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// redactURL hides the password of URLs with user info before logging
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
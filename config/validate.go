@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes a single configuration field that failed validation
+type FieldError struct {
+	// Key is the dotted configuration key, e.g. server.port
+	Key string
+	// Tag is the failing `validate` rule, e.g. required
+	Tag string
+	// Param is the rule parameter, e.g. 1 for min=1
+	Param string
+}
+
+func (e FieldError) String() string {
+	if e.Param != "" {
+		return fmt.Sprintf("%s: failed on '%s=%s'", e.Key, e.Tag, e.Param)
+	}
+	return fmt.Sprintf("%s: failed on '%s'", e.Key, e.Tag)
+}
+
+// ValidationError aggregates all field errors of a struct validation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.String())
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// UnmarshalAndValidate unmarshals the entire configuration into a struct
+// and validates it using its `validate` struct tags
+func (m *Manager) UnmarshalAndValidate(rawVal interface{}) error {
+	if err := m.Unmarshal(rawVal); err != nil {
+		return err
+	}
+	return validateStruct("", rawVal)
+}
+
+// UnmarshalKeyValidated unmarshals a configuration key into a struct like UnmarshalKey and
+// validates it using its `validate` struct tags. Failing fields are reported by their full
+// config key, e.g. server.port for the Port field when key is server.
+func (m *Manager) UnmarshalKeyValidated(key string, rawVal interface{}) error {
+	if err := m.UnmarshalKey(key, rawVal); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", key, err)
+	}
+	return validateStruct(key, rawVal)
+}
+
+// validateStruct validates rawVal and reports failing fields by their config key under prefix
+func validateStruct(prefix string, rawVal interface{}) error {
+	validate := validator.New()
+	// Report fields by their mapstructure name so errors match config keys
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("mapstructure"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return strings.ToLower(f.Name)
+		}
+		return name
+	})
+
+	err := validate.Struct(rawVal)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	result := &ValidationError{}
+	for _, fe := range validationErrors {
+		// Namespace is "<StructName>.<field>.<field>", drop the struct name
+		key := fe.Namespace()
+		if idx := strings.Index(key, "."); idx >= 0 {
+			key = key[idx+1:]
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		result.Fields = append(result.Fields, FieldError{
+			Key:   key,
+			Tag:   fe.Tag(),
+			Param: fe.Param(),
+		})
+	}
+	return result
+}
+
+// AddValidator registers a custom validation run by Validate, for rules struct tags cannot
+// express, e.g. cross-field checks or files that must exist on disk
+func (m *Manager) AddValidator(fn func(m *Manager) error) {
+	m.validators = append(m.validators, fn)
+}
+
+// Validate runs all validators registered with AddValidator and returns all their errors joined
+func (m *Manager) Validate() error {
+	var errs []error
+	for _, fn := range m.validators {
+		if err := fn(m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
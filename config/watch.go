@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchState is the file watcher started by the first Watch call
+type watchState struct {
+	watcher   *fsnotify.Watcher
+	callbacks []func(e fsnotify.Event)
+	done      chan struct{}
+}
+
+// Watch reloads the config file loaded by LoadFromFile whenever it changes on disk and then
+// calls onChange, e.g. to re-read feature flags without a restart. Further calls add callbacks
+// to the same watcher. The directory of the file is watched, so editors replacing the file and
+// Kubernetes ConfigMap symlink swaps are picked up; included files are not watched.
+// Readers block while a reload is applied, so they never see a half-loaded config. A reload that
+// fails is logged and keeps the previous settings. Replace the file atomically (write a temp
+// file and rename it), a file rewritten in place may be reloaded half written.
+func (m *Manager) Watch(onChange func(e fsnotify.Event)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.configFile == "" {
+		return errors.New("no config file loaded to watch")
+	}
+	if m.watch != nil {
+		m.watch.callbacks = append(m.watch.callbacks, onChange)
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	configFile := filepath.Clean(m.configFile)
+	if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	m.watch = &watchState{
+		watcher:   watcher,
+		callbacks: []func(e fsnotify.Event){onChange},
+		done:      make(chan struct{}),
+	}
+	go m.watchLoop(m.watch, configFile)
+	m.log.Infof("Watching config file: %s", configFile)
+	return nil
+}
+
+// StopWatching stops the watcher started by Watch and drops its callbacks
+func (m *Manager) StopWatching() {
+	m.mu.Lock()
+	state := m.watch
+	m.watch = nil
+	m.mu.Unlock()
+
+	if state == nil {
+		return
+	}
+	state.watcher.Close()
+	<-state.done
+}
+
+// watchLoop reloads configFile on watcher events until the watcher is closed
+func (m *Manager) watchLoop(state *watchState, configFile string) {
+	defer close(state.done)
+
+	realFile, _ := filepath.EvalSymlinks(configFile)
+	for {
+		select {
+		case event, ok := <-state.watcher.Events:
+			if !ok {
+				return
+			}
+			// a symlinked file, e.g. a ConfigMap, changes by swapping the link target
+			currentFile, _ := filepath.EvalSymlinks(configFile)
+			written := filepath.Clean(event.Name) == configFile && event.Has(fsnotify.Write|fsnotify.Create)
+			swapped := currentFile != "" && currentFile != realFile
+			if !written && !swapped {
+				continue
+			}
+			realFile = currentFile
+
+			if err := m.reloadFile(configFile); err != nil {
+				m.log.Errorf("Failed to reload config file %s: %v", configFile, err)
+				continue
+			}
+			for _, onChange := range m.watchCallbacks(state) {
+				onChange(event)
+			}
+		case err, ok := <-state.watcher.Errors:
+			if !ok {
+				return
+			}
+			m.log.Errorf("Config watcher error: %v", err)
+		}
+	}
+}
+
+// reloadFile reloads configFile like LoadFromFile and audits the changed keys
+func (m *Manager) reloadFile(configFile string) error {
+	defer m.timeLoad(configFile, time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	before := m.flatten()
+	if err := m.loadFile(configFile); err != nil {
+		return err
+	}
+	m.auditChanges(before, m.flatten(), AuditSourceFileReload)
+	return nil
+}
+
+// watchCallbacks returns a copy of the callbacks of state
+func (m *Manager) watchCallbacks(state *watchState) []func(e fsnotify.Event) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]func(e fsnotify.Event){}, state.callbacks...)
+}
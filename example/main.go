@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/letusgogo/quick/app"
+	"github.com/letusgogo/quick/component"
 	"github.com/letusgogo/quick/logger"
+	"github.com/letusgogo/quick/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
@@ -58,6 +65,9 @@ func main() {
 			"custom.api.key": "MY_CUSTOM_API_KEY", // Custom mapping example
 		}),
 
+		// Allow components 15s to stop
+		app.WithShutdownTimeout(15*time.Second),
+
 		// Add before hooks
 		app.AddBefore(func(c *cli.Context) error {
 			logger.GetLogger("main").Infof("Application starting in %s mode", c.String("mode"))
@@ -88,21 +98,22 @@ func runServer(c *cli.Context, myApp *app.App) error {
 	host := myApp.Config().GetString("server.host")
 	mode := c.String("mode")
 
-	// Get configuration values from struct
+	// Get configuration values from struct, SERVER_PORT and SERVER_HOST override the file
 	var serverConfig ServerConfig
-	envMappings := map[string]string{
-		"server.port": "SERVER_PORT",
-		"server.host": "SERVER_HOST",
-	}
-	if err := myApp.Config().UnmarshalKeyWithEnv("server", &serverConfig, envMappings); err != nil {
+	myApp.Config().BindStructEnv("server", &serverConfig)
+	if err := myApp.Config().UnmarshalKey("server", &serverConfig); err != nil {
 		log.Fatalf("Failed to unmarshal server config: %v", err)
 	}
 	log.Infof("Server config from struct, %s:%s", serverConfig.Host, serverConfig.Port)
 
 	log.Infof("Starting HTTP server on %s:%s in %s mode", host, port, mode)
 
-	// Simulate server startup
-	log.Info("Server started successfully")
+	// The app starts the server once this action returns and stops it on SIGINT/SIGTERM
+	server := utils.NewGinServer(net.JoinHostPort(host, port))
+	server.GinEngine().GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	myApp.RegisterComponent("http", server.Component())
 
 	// Log some configuration for debugging - these are bound from environment variables!
 	myApp.Config().LogConfigValue("server.port")        // From APP_SERVER_PORT
@@ -111,14 +122,6 @@ func runServer(c *cli.Context, myApp *app.App) error {
 	myApp.Config().LogConfigValue("redis.addr")         // From APP_REDIS_ADDR
 	myApp.Config().LogConfigValue("worker.concurrency") // From APP_WORKER_CONCURRENCY
 
-	// Wait for shutdown signal
-	app.WaitForSignal(func(s os.Signal) {
-		log.Infof("Received signal %v, shutting down HTTP server gracefully", s)
-		// Here you would normally stop your HTTP server
-		time.Sleep(1 * time.Second) // Simulate graceful shutdown
-		log.Info("HTTP server stopped")
-	})
-
 	return nil
 }
 
@@ -135,34 +138,34 @@ func runWorker(c *cli.Context, myApp *app.App) error {
 
 	log.Infof("Starting background worker with concurrency=%d in %s mode", concurrency, mode)
 
-	// Simulate worker tasks
+	// Start workers, the group joins them on stop
+	group := component.NewGroup(myApp.Context())
 	for i := 0; i < concurrency; i++ {
-		go func(id int) {
-			workerLog := log.WithField("worker_id", id)
+		workerLog := log.WithField("worker_id", i)
+		group.Go(func(ctx context.Context) error {
 			workerLog.Info("Worker started")
 
 			// Simulate work
-			ticker := time.NewTicker(5 * time.Second)
-			defer ticker.Stop()
-
-			for {
-				select {
-				case <-ticker.C:
-					workerLog.Info("Processing task...")
-				}
-			}
-		}(i)
+			app.PeriodicWorker(5*time.Second, func(ctx context.Context) error {
+				workerLog.Info("Processing task...")
+				return nil
+			}).Run(ctx)
+
+			workerLog.Info("Worker stopped")
+			return nil
+		})
 	}
 
 	log.Info("All workers started successfully")
 
-	// Wait for shutdown signal
-	app.WaitForSignal(func(s os.Signal) {
+	// Wait for shutdown signal, a failed stop fails the command
+	_, err := app.WaitForSignal(func(s os.Signal) error {
 		log.Infof("Received signal %v, shutting down workers gracefully", s)
-		// Here you would normally stop your workers
-		time.Sleep(2 * time.Second) // Simulate graceful shutdown
+		if err := group.Stop(10 * time.Second); err != nil {
+			return fmt.Errorf("stop workers: %w", err)
+		}
 		log.Info("All workers stopped")
+		return nil
 	})
-
-	return nil
+	return err
 }
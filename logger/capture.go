@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CapturedEntry is a log entry parsed from a CaptureForTest buffer
+type CapturedEntry struct {
+	Level   string
+	Message string
+	// Fields holds all other keys, including module and the caller's func and file
+	Fields map[string]interface{}
+}
+
+// CaptureForTest redirects the global logger to a buffer at debug level with json format,
+// bypassing module outputs and declared outputs, and returns the buffer and a function
+// restoring the previous settings. The logger is global, so tests using it must not run in parallel:
+//
+//	buf, restore := logger.CaptureForTest()
+//	defer restore()
+//	doWork()
+//	entries, _ := logger.ParseCaptured(buf)
+func CaptureForTest() (*bytes.Buffer, func()) {
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	std := logrus.StandardLogger()
+	prevOutput := std.Out
+	prevFormatter := std.Formatter
+	prevLevel := std.GetLevel()
+	prevReportCaller := std.ReportCaller
+	prevSinks := outputs.swapSinks(nil)
+	prevModuleOutputs := swapModuleOutputs(make(map[string]io.Writer))
+
+	buf := &bytes.Buffer{}
+	// logrus serializes writes with its own lock, the buffer needs no extra locking
+	logrus.SetOutput(buf)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	logrus.SetLevel(logrus.DebugLevel)
+	logrus.SetReportCaller(outputs.reportsCaller())
+
+	return buf, func() {
+		applyMu.Lock()
+		defer applyMu.Unlock()
+
+		logrus.SetOutput(prevOutput)
+		logrus.SetFormatter(prevFormatter)
+		logrus.SetLevel(prevLevel)
+		logrus.SetReportCaller(prevReportCaller)
+		outputs.swapSinks(prevSinks)
+		swapModuleOutputs(prevModuleOutputs)
+	}
+}
+
+// ParseCaptured parses the entries written to a CaptureForTest buffer
+func ParseCaptured(buf *bytes.Buffer) ([]CapturedEntry, error) {
+	var entries []CapturedEntry
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			return nil, fmt.Errorf("parse captured entry %q: %w", scanner.Text(), err)
+		}
+
+		entry := CapturedEntry{Fields: fields}
+		entry.Level, _ = fields[logrus.FieldKeyLevel].(string)
+		entry.Message, _ = fields[logrus.FieldKeyMsg].(string)
+		delete(fields, logrus.FieldKeyLevel)
+		delete(fields, logrus.FieldKeyMsg)
+		delete(fields, logrus.FieldKeyTime)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TraceIDField is the log field of the trace ID set with WithTraceID
+const TraceIDField = "trace_id"
+
+// traceIDKey is the context key of the trace ID
+type traceIDKey struct{}
+
+// fieldsKey is the context key of the fields added with WithFields
+type fieldsKey struct{}
+
+// WithTraceID returns a copy of ctx carrying the trace ID logged by FromContext
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// TraceID returns the trace ID set with WithTraceID, or "" if none
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey{}).(string)
+	return id
+}
+
+// WithFields returns a copy of ctx carrying fields logged by FromContext, merged with the
+// fields already in ctx; on conflicts the new fields win
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	existing, _ := ctx.Value(fieldsKey{}).(logrus.Fields)
+	merged := make(logrus.Fields, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext returns the default logger with the fields and trace ID carried by ctx, so log
+// lines within a request share them without passing an entry around. The entry also carries
+// ctx for hooks.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if ctx == nil {
+		return defaultLogger
+	}
+
+	entry := defaultLogger.WithContext(ctx)
+	if fields, ok := ctx.Value(fieldsKey{}).(logrus.Fields); ok {
+		entry = entry.WithFields(fields)
+	}
+	if id := TraceID(ctx); id != "" {
+		entry = entry.WithField(TraceIDField, id)
+	}
+	return entry
+}
@@ -2,9 +2,11 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"runtime"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
@@ -13,6 +15,22 @@ import (
 type Config struct {
 	Level  string `mapstructure:"level"`
 	Format string `mapstructure:"format"`
+	// Outputs declares multiple log sinks with their own format and level.
+	// When empty, logs go to File or InitOptions.Output with Level and Format.
+	Outputs []OutputConfig `mapstructure:"outputs"`
+
+	// File writes logs to a file rotated by size and age instead of InitOptions.Output
+	File string `mapstructure:"file"`
+	// MaxSizeMB is the size in megabytes at which File is rotated (default: 100)
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+	// MaxBackups is the number of rotated files kept, 0 keeps all (subject to MaxAgeDays)
+	MaxBackups int `mapstructure:"max_backups"`
+	// MaxAgeDays is the number of days rotated files are kept, 0 keeps them regardless of age
+	MaxAgeDays int `mapstructure:"max_age_days"`
+	// Compress gzips rotated files
+	Compress bool `mapstructure:"compress"`
+	// TeeStdout also writes logs to InitOptions.Output (default: os.Stdout) when File is set
+	TeeStdout bool `mapstructure:"tee_stdout"`
 }
 
 // DefaultConfig returns default logger configuration
@@ -30,48 +48,143 @@ func Init(config Config) error {
 
 // InitOptions provides additional options for logger initialization
 type InitOptions struct {
-	// Output can be set to redirect log output (default: os.Stdout)
-	Output *os.File
+	// Output can be set to redirect log output to any writer, e.g. a buffer in tests or a
+	// rotating file writer (default: os.Stdout)
+	Output io.Writer
 	// AddTimestamp controls whether to add timestamp to logs (default: true)
 	AddTimestamp bool
 	// ForceColors controls whether to force colors in text format (default: true for text format)
 	ForceColors *bool
 	// ReportCaller controls whether to report caller info (default: true)
 	ReportCaller bool
+	// SchemaVersion, when set with json format, emits NDJSON with a stable set of
+	// top-level keys (ts, level, msg, module, caller) plus a constant schema_version
+	SchemaVersion string
 }
 
 // InitWithOptions initializes the global logger with configuration and options
 func InitWithOptions(config Config, options InitOptions) error {
+	if err := apply(config, options); err != nil {
+		return err
+	}
+
+	logrus.Infof("Logger initialized with level=%s, format=%s", config.Level, config.Format)
+	return nil
+}
+
+// Reconfigure re-applies level, output and formatter of the global logger at runtime,
+// e.g. to switch from text to json without restarting. Formatter, outputs, levels and
+// caller reporting are built first and published together in one swap, so an invalid
+// config leaves the logger untouched and a log call racing with Reconfigure is written
+// with either the old or the new settings, never a mix of both.
+func Reconfigure(config Config, options InitOptions) error {
+	if err := apply(config, options); err != nil {
+		return err
+	}
+
+	logrus.Infof("Logger reconfigured with level=%s, format=%s", config.Level, config.Format)
+	return nil
+}
+
+// SetLevel changes the level of the global logger at runtime, e.g. from a SIGHUP handler or an
+// admin endpoint to log debug while reproducing an issue. Formatters and outputs are kept.
+// Config.File and outputs without their own level switch to level; outputs declaring a
+// level in Config.Outputs keep it. An unknown level returns an error and leaves the level
+// untouched.
+func SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	applyMu.Lock()
+	logrus.SetLevel(outputs.setLevel(parsed))
+	applyMu.Unlock()
+
+	logrus.Infof("Log level set to %s", parsed)
+	return nil
+}
+
+// applyMu serializes concurrent InitWithOptions/Reconfigure calls
+var applyMu sync.Mutex
+
+// apply validates config and options, then applies them to the global logger
+func apply(config Config, options InitOptions) error {
+	formatter, err := newFormatter(config, options)
+	if err != nil {
+		return err
+	}
+
 	// Parse log level
 	parsedLevel, err := logrus.ParseLevel(config.Level)
 	if err != nil {
 		logrus.Warnf("Invalid log level '%s', using info level", config.Level)
 		parsedLevel = logrus.InfoLevel
 	}
-	logrus.SetLevel(parsedLevel)
 
 	// Set output
-	output := options.Output
-	if output == nil {
-		output = os.Stdout
+	writer := options.Output
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	// Open the declared outputs, or the single output; entries fan out through a hook
+	var sinks []*sink
+	if len(config.Outputs) > 0 || config.File != "" {
+		open := openSinks
+		if len(config.Outputs) == 0 {
+			open = fileSinks
+		}
+		var maxLevel logrus.Level
+		if sinks, maxLevel, err = open(config, options); err != nil {
+			return err
+		}
+		parsedLevel = maxLevel
+		formatter = sinks[0].formatter
+	} else {
+		sinks = []*sink{{writer: writer, formatter: formatter, level: parsedLevel}}
+	}
+	for _, s := range sinks {
+		s.logger = formattingLogger(s.writer, options.ReportCaller)
+	}
+
+	applyMu.Lock()
+	defer applyMu.Unlock()
+
+	// The sinks filter by level, the global level only gates entries before the hooks run.
+	// It lets entries of both the old and the new level through while they are swapped.
+	// Callers are reported by the hook too, following the published settings.
+	std := logrus.StandardLogger()
+	if parsedLevel > std.GetLevel() {
+		logrus.SetLevel(parsedLevel)
+	}
+	if std.ReportCaller {
+		logrus.SetReportCaller(false)
 	}
-	logrus.SetOutput(output)
+	logrus.SetOutput(io.Discard)
+	logrus.SetFormatter(&routingFormatter{inner: formatter, discard: true})
 
-	// Set caller reporting
-	reportCaller := options.ReportCaller
-	logrus.SetReportCaller(reportCaller)
+	outputs.publish(sinks, formatter, options.ReportCaller)
 
-	// Set formatter based on format
+	logrus.SetLevel(parsedLevel)
+	return nil
+}
+
+// newFormatter creates the formatter for the configured format
+func newFormatter(config Config, options InitOptions) (logrus.Formatter, error) {
 	switch config.Format {
 	case "json":
-		logrus.SetFormatter(&logrus.JSONFormatter{
+		if options.SchemaVersion != "" {
+			return newNDJSONFormatter(options.SchemaVersion), nil
+		}
+		return &logrus.JSONFormatter{
 			TimestampFormat: "2006-01-02 15:04:05",
 			CallerPrettyfier: func(f *runtime.Frame) (string, string) {
 				fileName := fmt.Sprintf("%s:%d", path.Base(f.File), f.Line)
 				funcName := path.Base(f.Function)
 				return funcName, fileName
 			},
-		})
+		}, nil
 	case "text":
 		forceColors := true
 		if options.ForceColors != nil {
@@ -83,17 +196,14 @@ func InitWithOptions(config Config, options InitOptions) error {
 			addTimestamp = false
 		}
 
-		logrus.SetFormatter(&logrus.TextFormatter{
+		return &logrus.TextFormatter{
 			FullTimestamp: addTimestamp,
 			ForceColors:   forceColors,
 			PadLevelText:  true,
-		})
+		}, nil
 	default:
-		return fmt.Errorf("unsupported log format: %s", config.Format)
+		return nil, fmt.Errorf("unsupported log format: %s", config.Format)
 	}
-
-	logrus.Infof("Logger initialized with level=%s, format=%s", config.Level, config.Format)
-	return nil
 }
 
 // NewLogger creates a new logger instance with the given module name
@@ -103,9 +213,18 @@ func NewLogger(module string) *logrus.Entry {
 	})
 }
 
-// GetLogger returns a logger with the given module name
+// moduleLoggers caches the entries returned by GetLogger, keyed by module name
+var moduleLoggers sync.Map
+
+// GetLogger returns a logger with the given module name.
+// Entries are memoized per module since their fields never change; level, formatter
+// and output live on the global logger, so runtime changes still apply.
 func GetLogger(module string) *logrus.Entry {
-	return NewLogger(module)
+	if entry, ok := moduleLoggers.Load(module); ok {
+		return entry.(*logrus.Entry)
+	}
+	entry, _ := moduleLoggers.LoadOrStore(module, NewLogger(module))
+	return entry.(*logrus.Entry)
 }
 
 // Default logger instance
@@ -0,0 +1,376 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetLoggerIsMemoized(t *testing.T) {
+	first := GetLogger("memo")
+	second := GetLogger("memo")
+	if first != second {
+		t.Error("Expected GetLogger to return the same entry for a module")
+	}
+	if first.Data["module"] != "memo" {
+		t.Errorf("Expected module field to be 'memo', got '%v'", first.Data["module"])
+	}
+	if GetLogger("other") == first {
+		t.Error("Expected different modules to get different entries")
+	}
+}
+
+func BenchmarkGetLogger(b *testing.B) {
+	logrus.SetOutput(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		GetLogger("bench").Debug("message")
+	}
+}
+
+func BenchmarkNewLogger(b *testing.B) {
+	logrus.SetOutput(io.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewLogger("bench").Debug("message")
+	}
+}
+
+func TestReconfigureKeepsLoggerOnInvalidFormat(t *testing.T) {
+	if err := Reconfigure(Config{Level: "debug", Format: "json"}, InitOptions{Output: os.Stderr}); err != nil {
+		t.Fatalf("Failed to reconfigure: %v", err)
+	}
+	if err := Reconfigure(Config{Level: "error", Format: "xml"}, InitOptions{}); err == nil {
+		t.Fatal("Expected error for unsupported format")
+	}
+
+	if logrus.GetLevel() != logrus.DebugLevel {
+		t.Errorf("Expected level to stay debug, got %s", logrus.GetLevel())
+	}
+	if _, ok := logrus.StandardLogger().Formatter.(*routingFormatter).inner.(*logrus.JSONFormatter); !ok {
+		t.Errorf("Expected formatter to stay json, got %T", logrus.StandardLogger().Formatter)
+	}
+}
+
+func TestReconfigureIsAtomic(t *testing.T) {
+	var text, json bytes.Buffer
+	noColors := false
+	textConfig := func() error {
+		return Reconfigure(Config{Level: "info", Format: "text"}, InitOptions{Output: &text, ForceColors: &noColors})
+	}
+	jsonConfig := func() error {
+		return Reconfigure(Config{Level: "debug", Format: "json"}, InitOptions{Output: &json, ReportCaller: true})
+	}
+	if err := textConfig(); err != nil {
+		t.Fatalf("Failed to reconfigure: %v", err)
+	}
+	defer InitWithOptions(DefaultConfig(), InitOptions{})
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					GetLogger("atomic").Info("info message")
+					GetLogger("atomic").Debug("debug message")
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		configure := textConfig
+		if i%2 == 0 {
+			configure = jsonConfig
+		}
+		if err := configure(); err != nil {
+			t.Fatalf("Failed to reconfigure: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSpace(text.String()), "\n") {
+		if strings.HasPrefix(line, "{") || strings.Contains(line, "debug message") || strings.Contains(line, "logger_test.go") {
+			t.Fatalf("Expected only text info lines without caller in the text output, got %q", line)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(json.String()), "\n") {
+		if !strings.HasPrefix(line, "{") || !strings.Contains(line, `"file":`) {
+			t.Fatalf("Expected only json lines with caller in the json output, got %q", line)
+		}
+	}
+	if !strings.Contains(json.String(), "debug message") {
+		t.Error("Expected debug messages in the json output")
+	}
+}
+
+func TestInitWithWriterOutput(t *testing.T) {
+	var buf bytes.Buffer
+	if err := InitWithOptions(Config{Level: "info", Format: "json"}, InitOptions{Output: &buf}); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	defer logrus.SetOutput(os.Stderr)
+
+	GetLogger("writer").Info("to buffer")
+	if !strings.Contains(buf.String(), `"msg":"to buffer"`) {
+		t.Errorf("Expected log line in buffer, got %q", buf.String())
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := InitWithOptions(Config{Level: "info", Format: "json"}, InitOptions{Output: &buf}); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	defer InitWithOptions(DefaultConfig(), InitOptions{})
+
+	GetLogger("level").Debug("hidden")
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("Failed to set level: %v", err)
+	}
+	GetLogger("level").Debug("shown")
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("Expected only the debug message after SetLevel, got %q", buf.String())
+	}
+	if _, ok := logrus.StandardLogger().Formatter.(*routingFormatter).inner.(*logrus.JSONFormatter); !ok {
+		t.Errorf("Expected formatter to stay json, got %T", logrus.StandardLogger().Formatter)
+	}
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+	if logrus.GetLevel() != logrus.DebugLevel {
+		t.Errorf("Expected level to stay debug, got %s", logrus.GetLevel())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := WithTraceID(context.Background(), "abc123")
+	ctx = WithFields(ctx, logrus.Fields{"user_id": 42, "tenant": "a"})
+	ctx = WithFields(ctx, logrus.Fields{"tenant": "b"})
+
+	entry := FromContext(ctx)
+	if entry.Data[TraceIDField] != "abc123" {
+		t.Errorf("Expected trace ID abc123, got %v", entry.Data[TraceIDField])
+	}
+	if entry.Data["user_id"] != 42 || entry.Data["tenant"] != "b" {
+		t.Errorf("Unexpected fields: %v", entry.Data)
+	}
+	if entry.Context != ctx {
+		t.Error("Expected entry to carry the context")
+	}
+
+	if entry := FromContext(context.Background()); entry.Data[TraceIDField] != nil {
+		t.Errorf("Expected no trace ID without one in the context, got %v", entry.Data[TraceIDField])
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	if err := InitWithOptions(Config{Level: "info", Format: "json"}, InitOptions{Output: &buf}); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	defer InitWithOptions(DefaultConfig(), InitOptions{})
+
+	log := slog.New(NewSlogHandler()).With("lib", "client").WithGroup("req")
+	log.Debug("hidden")
+	log.WarnContext(WithTraceID(context.Background(), "t1"), "retrying", "attempt", 2, slog.Group("backoff", "ms", 100))
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") {
+		t.Errorf("Expected debug record to be filtered, got %q", out)
+	}
+	for _, want := range []string{`"level":"warning"`, `"msg":"retrying"`, `"lib":"client"`, `"req.attempt":2`, `"req.backoff.ms":100`, `"trace_id":"t1"`, `"module":"default"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %s in output, got %q", want, out)
+		}
+	}
+}
+
+func TestSetModuleOutput(t *testing.T) {
+	var global, audit bytes.Buffer
+	if err := InitWithOptions(Config{Level: "info", Format: "json"}, InitOptions{Output: &global}); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	defer InitWithOptions(DefaultConfig(), InitOptions{})
+	SetModuleOutput("audit", &audit)
+	defer SetModuleOutput("audit", nil)
+
+	GetLogger("audit").Info("user deleted")
+	GetLogger("server").Info("request served")
+
+	if !strings.Contains(audit.String(), "user deleted") || strings.Contains(audit.String(), "request served") {
+		t.Errorf("Unexpected audit output: %s", audit.String())
+	}
+	if !strings.Contains(global.String(), "request served") || strings.Contains(global.String(), "user deleted") {
+		t.Errorf("Unexpected global output: %s", global.String())
+	}
+
+	// formatting an entry, e.g. from another hook, must not write it anywhere
+	entry := GetLogger("audit").WithField("id", 1)
+	entry.Message = "formatted only"
+	if _, err := logrus.StandardLogger().Formatter.Format(entry); err != nil {
+		t.Fatalf("Failed to format entry: %v", err)
+	}
+	if strings.Contains(audit.String(), "formatted only") {
+		t.Errorf("Expected Format to have no side effects, got audit output: %s", audit.String())
+	}
+}
+
+func TestOutputsFanOut(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := dir + "/app.log"
+	config := Config{
+		Level:  "info",
+		Format: "text",
+		Outputs: []OutputConfig{
+			{Type: OutputFile, Path: dir + "/errors.log", Level: "error"},
+			{Type: OutputFile, Path: jsonFile, Format: "json", Level: "debug"},
+		},
+	}
+	if err := InitWithOptions(config, InitOptions{}); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	defer InitWithOptions(DefaultConfig(), InitOptions{})
+
+	GetLogger("outputs").Debug("debug message")
+	GetLogger("outputs").Error("error message")
+
+	errorsLog, _ := os.ReadFile(dir + "/errors.log")
+	if strings.Contains(string(errorsLog), "debug message") || !strings.Contains(string(errorsLog), "error message") {
+		t.Errorf("Unexpected error output: %s", errorsLog)
+	}
+	jsonLog, _ := os.ReadFile(jsonFile)
+	if !strings.Contains(string(jsonLog), `"msg":"debug message"`) {
+		t.Errorf("Expected json output to contain debug message: %s", jsonLog)
+	}
+}
+
+func TestSetLevelKeepsOutputLevels(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{
+		Level:  "info",
+		Format: "text",
+		Outputs: []OutputConfig{
+			{Type: OutputFile, Path: dir + "/errors.log", Level: "error"},
+			{Type: OutputFile, Path: dir + "/app.log"},
+		},
+	}
+	if err := InitWithOptions(config, InitOptions{}); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	defer InitWithOptions(DefaultConfig(), InitOptions{})
+
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("Failed to set level: %v", err)
+	}
+	GetLogger("outputs").Debug("debug message")
+	if err := SetLevel("warn"); err != nil {
+		t.Fatalf("Failed to set level: %v", err)
+	}
+	GetLogger("outputs").Info("info message")
+	GetLogger("outputs").Error("error message")
+
+	errorsLog, _ := os.ReadFile(dir + "/errors.log")
+	if strings.Contains(string(errorsLog), "debug message") || !strings.Contains(string(errorsLog), "error message") {
+		t.Errorf("Expected the error output to keep its level, got %s", errorsLog)
+	}
+	appLog, _ := os.ReadFile(dir + "/app.log")
+	if !strings.Contains(string(appLog), "debug message") || strings.Contains(string(appLog), "info message") {
+		t.Errorf("Expected the inheriting output to follow SetLevel, got %s", appLog)
+	}
+}
+
+func TestRotatingFileWithTee(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			file := t.TempDir() + "/app.log"
+			var console bytes.Buffer
+			config := Config{Level: "info", Format: format, File: file, MaxSizeMB: 1, TeeStdout: true}
+			if err := InitWithOptions(config, InitOptions{Output: &console}); err != nil {
+				t.Fatalf("Failed to init logger: %v", err)
+			}
+			defer InitWithOptions(DefaultConfig(), InitOptions{})
+
+			GetLogger("rotate").Info("persisted message")
+
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read log file: %v", err)
+			}
+			if !strings.Contains(string(data), "persisted message") {
+				t.Errorf("Expected message in log file, got %q", data)
+			}
+			if strings.Contains(string(data), "\x1b[") {
+				t.Errorf("Expected no color codes in log file, got %q", data)
+			}
+			if !strings.Contains(console.String(), "persisted message") {
+				t.Errorf("Expected message teed to console, got %q", console.String())
+			}
+		})
+	}
+}
+
+func TestOutputsRequirePath(t *testing.T) {
+	config := Config{Level: "info", Format: "text", Outputs: []OutputConfig{{Type: OutputFile}}}
+	if err := InitWithOptions(config, InitOptions{}); err == nil {
+		t.Error("Expected error for file output without path")
+	}
+}
+
+func TestRecentErrorsHook(t *testing.T) {
+	hook := RecentErrorsHook(2)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(hook)
+
+	logger.Error("first")
+	logger.Warn("ignored")
+	logger.WithField("id", 1).Error("second")
+	logger.Error("third")
+
+	entries := hook.Entries()
+	if len(entries) != 2 || entries[0].Message != "third" || entries[1].Message != "second" {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+	if entries[1].Fields["id"] != 1 {
+		t.Errorf("Expected fields to be kept, got %v", entries[1].Fields)
+	}
+}
+
+func TestCaptureForTest(t *testing.T) {
+	buf, restore := CaptureForTest()
+	GetLogger("billing").WithField("invoice", "inv-1").Error("charge failed")
+	restore()
+	GetLogger("billing").Error("not captured")
+
+	entries, err := ParseCaptured(buf)
+	if err != nil {
+		t.Fatalf("Failed to parse captured entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 captured entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Level != "error" || entry.Message != "charge failed" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.Fields["module"] != "billing" || entry.Fields["invoice"] != "inv-1" {
+		t.Errorf("Expected module and invoice fields, got %v", entry.Fields)
+	}
+}
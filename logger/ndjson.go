@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Stable top-level keys of the NDJSON log schema
+const (
+	FieldSchemaVersion = "schema_version"
+	FieldTimestamp     = "ts"
+	FieldLevel         = "level"
+	FieldMessage       = "msg"
+	FieldModule        = "module"
+	FieldCaller        = "caller"
+)
+
+// ndjsonFormatter writes exactly one compact JSON object per line with a fixed schema
+type ndjsonFormatter struct {
+	schemaVersion string
+	json          *logrus.JSONFormatter
+}
+
+func newNDJSONFormatter(schemaVersion string) *ndjsonFormatter {
+	return &ndjsonFormatter{
+		schemaVersion: schemaVersion,
+		json: &logrus.JSONFormatter{
+			TimestampFormat: "2006-01-02 15:04:05",
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime:  FieldTimestamp,
+				logrus.FieldKeyLevel: FieldLevel,
+				logrus.FieldKeyMsg:   FieldMessage,
+				logrus.FieldKeyFile:  FieldCaller,
+			},
+			// Report the caller as a single file:line value, no separate func key
+			CallerPrettyfier: func(f *runtime.Frame) (string, string) {
+				return "", fmt.Sprintf("%s:%d", path.Base(f.File), f.Line)
+			},
+		},
+	}
+}
+
+// Format implements logrus.Formatter
+func (f *ndjsonFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data)+3)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	data[FieldSchemaVersion] = f.schemaVersion
+	if _, ok := data[FieldModule]; !ok {
+		data[FieldModule] = ""
+	}
+	if !entry.HasCaller() {
+		data[FieldCaller] = ""
+	}
+
+	e := *entry
+	e.Data = data
+	return f.json.Format(&e)
+}
@@ -0,0 +1,277 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Output types of OutputConfig
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// OutputConfig is a log sink with its own format and level, e.g. pretty text to the
+// console and json to a file
+type OutputConfig struct {
+	// Type is stdout, stderr or file
+	Type string `mapstructure:"type"`
+	// Path is the file path for the file type
+	Path string `mapstructure:"path"`
+	// Format is text or json, defaults to Config.Format
+	Format string `mapstructure:"format"`
+	// Level is the minimum level written, defaults to Config.Level
+	Level string `mapstructure:"level"`
+}
+
+// sink is an opened log output
+type sink struct {
+	mu        sync.Mutex
+	writer    io.Writer
+	closer    io.Closer
+	formatter logrus.Formatter
+	level     logrus.Level
+	// fixed is set when the output declares its own level, SetLevel then keeps it
+	fixed bool
+	// logger is set on the entries formatted for the sink, so caller reporting and terminal
+	// detection follow the published settings rather than the global logger's
+	logger *logrus.Logger
+}
+
+// formattingLogger returns the logger set on entries formatted for w
+func formattingLogger(w io.Writer, reportCaller bool) *logrus.Logger {
+	return &logrus.Logger{Out: w, ReportCaller: reportCaller}
+}
+
+// logrusPackage prefixes the functions of logrus in stack frames
+const logrusPackage = "github.com/sirupsen/logrus."
+
+// callerFrame returns the frame that logged the entry being fired, the first frame outside
+// logrus after the logrus frames, as logrus reports callers
+func callerFrame() *runtime.Frame {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	inLogrus := false
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, logrusPackage) {
+			inLogrus = true
+		} else if inLogrus {
+			return &frame
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// withCaller returns entry with its caller set if the published settings report callers.
+// The global logger never reports callers itself, so the caller follows the settings the
+// entry is written with. The caller must hold h.mu.
+func (h *fanoutHook) withCaller(entry *logrus.Entry) *logrus.Entry {
+	if !h.reportCaller || entry.Caller != nil {
+		return entry
+	}
+	withCaller := *entry
+	withCaller.Caller = callerFrame()
+	return &withCaller
+}
+
+// format formats entry for the sink
+func (s *sink) format(entry *logrus.Entry) ([]byte, error) {
+	formatted := *entry
+	formatted.Logger = s.logger
+	return s.formatter.Format(&formatted)
+}
+
+// openSinks validates the outputs and opens their writers
+func openSinks(config Config, options InitOptions) ([]*sink, logrus.Level, error) {
+	var sinks []*sink
+	closeAll := func() {
+		for _, s := range sinks {
+			if s.closer != nil {
+				s.closer.Close()
+			}
+		}
+	}
+
+	maxLevel := logrus.PanicLevel
+	for i, output := range config.Outputs {
+		format := output.Format
+		if format == "" {
+			format = config.Format
+		}
+		formatter, err := newFormatter(Config{Level: output.Level, Format: format}, options)
+		if err != nil {
+			closeAll()
+			return nil, 0, fmt.Errorf("log output %d: %w", i, err)
+		}
+
+		levelName := output.Level
+		if levelName == "" {
+			levelName = config.Level
+		}
+		level, err := logrus.ParseLevel(levelName)
+		if err != nil {
+			closeAll()
+			return nil, 0, fmt.Errorf("log output %d: %w", i, err)
+		}
+		if level > maxLevel {
+			maxLevel = level
+		}
+
+		s := &sink{formatter: formatter, level: level, fixed: output.Level != ""}
+		switch output.Type {
+		case OutputStdout, "":
+			s.writer = os.Stdout
+		case OutputStderr:
+			s.writer = os.Stderr
+		case OutputFile:
+			if output.Path == "" {
+				closeAll()
+				return nil, 0, fmt.Errorf("log output %d: file output requires a path", i)
+			}
+			f, err := os.OpenFile(output.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				closeAll()
+				return nil, 0, fmt.Errorf("log output %d: %w", i, err)
+			}
+			s.writer, s.closer = f, f
+		default:
+			closeAll()
+			return nil, 0, fmt.Errorf("log output %d: unsupported type: %s", i, output.Type)
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, maxLevel, nil
+}
+
+// fanoutHook writes every entry to all sinks whose level allows it. The sinks, the formatter
+// of routed modules and caller reporting are published together under mu, so an entry is
+// written with either the old or the new settings of a Reconfigure, never a mix.
+type fanoutHook struct {
+	mu           sync.RWMutex
+	sinks        []*sink
+	formatter    logrus.Formatter
+	reportCaller bool
+}
+
+// outputs is installed on the global logger by the first apply and fed with sinks
+var (
+	outputs          = &fanoutHook{formatter: &logrus.TextFormatter{}}
+	installOutputsMu sync.Once
+)
+
+// publish replaces the sinks, the formatter of routed modules and caller reporting in one
+// step, then closes the previous file outputs
+func (h *fanoutHook) publish(sinks []*sink, formatter logrus.Formatter, reportCaller bool) {
+	installOutputsMu.Do(func() {
+		logrus.AddHook(outputs)
+	})
+
+	h.mu.Lock()
+	old := h.sinks
+	h.sinks, h.formatter, h.reportCaller = sinks, formatter, reportCaller
+	h.mu.Unlock()
+
+	for _, s := range old {
+		if s.closer != nil {
+			s.mu.Lock()
+			s.closer.Close()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// setLevel sets the level of the sinks inheriting Config.Level and returns the level the
+// global logger needs so that every sink still receives its entries
+func (h *fanoutHook) setLevel(level logrus.Level) logrus.Level {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	maxLevel := level
+	for _, s := range h.sinks {
+		if !s.fixed {
+			s.level = level
+		}
+		if s.level > maxLevel {
+			maxLevel = s.level
+		}
+	}
+	return maxLevel
+}
+
+// swapSinks replaces the sinks without closing the previous ones and returns them
+func (h *fanoutHook) swapSinks(sinks []*sink) []*sink {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old := h.sinks
+	h.sinks = sinks
+	return old
+}
+
+// Levels implements logrus.Hook
+func (h *fanoutHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *fanoutHook) Fire(entry *logrus.Entry) error {
+	// routed modules are written by the routes hook
+	if _, routed := moduleOutput(entry); routed {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.sinks) > 0 {
+		entry = h.withCaller(entry)
+	}
+	for _, s := range h.sinks {
+		if entry.Level > s.level {
+			continue
+		}
+		serialized, err := s.format(entry)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		_, err = s.writer.Write(serialized)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFormatter sets the formatter of routed modules, e.g. before the first apply
+func (h *fanoutHook) setFormatter(formatter logrus.Formatter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.formatter = formatter
+}
+
+// formatRouted formats an entry of a routed module written to w with the published formatter
+func (h *fanoutHook) formatRouted(entry *logrus.Entry, w io.Writer) ([]byte, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	formatted := *h.withCaller(entry)
+	formatted.Logger = formattingLogger(w, h.reportCaller)
+	return h.formatter.Format(&formatted)
+}
+
+// reportsCaller reports whether the published settings report callers
+func (h *fanoutHook) reportsCaller() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.reportCaller
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorEntry is a logged error kept by RecentErrors
+type ErrorEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// RecentErrors is a logrus hook keeping the last entries logged at error level or above
+type RecentErrors struct {
+	mu   sync.Mutex
+	buf  []ErrorEntry
+	next int
+	full bool
+}
+
+// RecentErrorsHook creates a hook keeping the last n error entries. Register it on the
+// global logger with logrus.AddHook(hook), then read them with Entries or serve them
+// over HTTP, e.g. server.DebugGroup().GET("/errors", gin.WrapH(hook)).
+func RecentErrorsHook(n int) *RecentErrors {
+	if n <= 0 {
+		n = 100
+	}
+	return &RecentErrors{buf: make([]ErrorEntry, n)}
+}
+
+// Levels implements logrus.Hook
+func (h *RecentErrors) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire implements logrus.Hook
+func (h *RecentErrors) Fire(entry *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(entry.Data))
+	for k, v := range entry.Data {
+		// errors marshal to {} in json, keep their message
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[k] = v
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf[h.next] = ErrorEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Fields:  fields,
+	}
+	h.next = (h.next + 1) % len(h.buf)
+	if h.next == 0 {
+		h.full = true
+	}
+	return nil
+}
+
+// Entries returns the recent error entries, newest first
+func (h *RecentErrors) Entries() []ErrorEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := h.next
+	if h.full {
+		n = len(h.buf)
+	}
+	entries := make([]ErrorEntry, 0, n)
+	for i := 1; i <= n; i++ {
+		entries = append(entries, h.buf[(h.next-i+len(h.buf))%len(h.buf)])
+	}
+	return entries
+}
+
+// ServeHTTP serves the recent error entries as JSON
+func (h *RecentErrors) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": h.Entries(),
+	})
+}
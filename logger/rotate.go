@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// fileSinks opens the rotating Config.File and, with TeeStdout, the console output
+// (InitOptions.Output or os.Stdout). Text written to the file is never colored.
+func fileSinks(config Config, options InitOptions) ([]*sink, logrus.Level, error) {
+	level, err := logrus.ParseLevel(config.Level)
+	if err != nil {
+		return nil, 0, fmt.Errorf("log file: %w", err)
+	}
+
+	fileOptions := options
+	noColors := false
+	fileOptions.ForceColors = &noColors
+	fileFormatter, err := newFormatter(config, fileOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rotating := &lumberjack.Logger{
+		Filename:   config.File,
+		MaxSize:    config.MaxSizeMB,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAgeDays,
+		Compress:   config.Compress,
+	}
+	sinks := []*sink{{writer: rotating, closer: rotating, formatter: fileFormatter, level: level}}
+
+	if config.TeeStdout {
+		formatter, err := newFormatter(config, options)
+		if err != nil {
+			rotating.Close()
+			return nil, 0, err
+		}
+		console := options.Output
+		if console == nil {
+			console = os.Stdout
+		}
+		sinks = append(sinks, &sink{writer: console, formatter: formatter, level: level})
+	}
+	return sinks, level, nil
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"io"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// moduleOutputs maps module names to dedicated writers
+var (
+	moduleOutputsMu sync.RWMutex
+	moduleOutputs   = make(map[string]io.Writer)
+)
+
+// SetModuleOutput routes logs of module (the "module" field set by GetLogger) to w instead of
+// the global output, e.g. to write audit logs to a separate file. Entries are formatted with
+// the global formatter, so format stays consistent across destinations.
+// A nil w routes the module back to the global output.
+func SetModuleOutput(module string, w io.Writer) {
+	moduleOutputsMu.Lock()
+	if w == nil {
+		delete(moduleOutputs, module)
+	} else {
+		moduleOutputs[module] = w
+	}
+	moduleOutputsMu.Unlock()
+
+	installRoutesOnce.Do(func() {
+		logrus.AddHook(routes)
+	})
+	// make sure routed entries are kept out of the global output even before InitWithOptions
+	applyMu.Lock()
+	defer applyMu.Unlock()
+	if _, ok := logrus.StandardLogger().Formatter.(*routingFormatter); !ok {
+		formatter := logrus.StandardLogger().Formatter
+		outputs.setFormatter(formatter)
+		logrus.SetFormatter(&routingFormatter{inner: formatter})
+	}
+}
+
+// routingFormatter formats entries for the global output, leaving out entries of routed
+// modules, which the routes hook writes. With discard set, other entries are left out as
+// well, which is used once apply publishes the outputs to the fanout hook; inner is then
+// only the configured formatter for reference.
+type routingFormatter struct {
+	inner   logrus.Formatter
+	discard bool
+}
+
+// Format implements logrus.Formatter
+func (f *routingFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if _, routed := moduleOutput(entry); routed || f.discard {
+		return nil, nil
+	}
+	return f.inner.Format(entry)
+}
+
+// routeHook writes entries of routed modules to their own writer, formatted with the
+// formatter published to the outputs
+type routeHook struct {
+	mu sync.Mutex
+}
+
+// routes is installed on the global logger by the first SetModuleOutput call
+var (
+	routes            = &routeHook{}
+	installRoutesOnce sync.Once
+)
+
+// Levels implements logrus.Hook
+func (h *routeHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook. Writes are serialized, logrus does not lock around hooks.
+func (h *routeHook) Fire(entry *logrus.Entry) error {
+	w, routed := moduleOutput(entry)
+	if !routed {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	serialized, err := outputs.formatRouted(entry, w)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(serialized)
+	return err
+}
+
+// moduleOutput returns the dedicated writer of the entry's module, if any
+func moduleOutput(entry *logrus.Entry) (io.Writer, bool) {
+	module, _ := entry.Data["module"].(string)
+	moduleOutputsMu.RLock()
+	defer moduleOutputsMu.RUnlock()
+	w, ok := moduleOutputs[module]
+	return w, ok
+}
+
+// swapModuleOutputs replaces the module outputs and returns the previous ones
+func swapModuleOutputs(outputs map[string]io.Writer) map[string]io.Writer {
+	moduleOutputsMu.Lock()
+	defer moduleOutputsMu.Unlock()
+	old := moduleOutputs
+	moduleOutputs = outputs
+	return old
+}
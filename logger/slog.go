@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slogHandler is a slog.Handler writing records through the logrus pipeline
+type slogHandler struct {
+	// fields are the attributes added with WithAttrs, keys already qualified by their groups
+	fields logrus.Fields
+	// prefix qualifies the keys of later attributes with the groups opened by WithGroup
+	prefix string
+}
+
+// NewSlogHandler returns a slog.Handler that logs through the configured logrus output, so
+// libraries using log/slog share the level, format and sinks of the rest of the app.
+// Records carry the default module field plus the fields of FromContext; attributes become
+// logrus fields, with group members keyed as "group.key".
+func NewSlogHandler() slog.Handler {
+	return &slogHandler{fields: logrus.Fields{}}
+}
+
+// Enabled reports whether the logrus level lets records of the given level through
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return logrus.IsLevelEnabled(logrusLevel(level))
+}
+
+// Handle logs the record with its attributes as fields
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(logrus.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.prefix, a)
+		return true
+	})
+
+	entry := FromContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		entry = entry.WithTime(r.Time)
+	}
+	entry.Log(logrusLevel(r.Level), r.Message)
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every record
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(logrus.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.prefix, a)
+	}
+	return &slogHandler{fields: fields, prefix: h.prefix}
+}
+
+// WithGroup returns a handler qualifying the keys of later attributes with name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// addAttr stores a in fields under prefix, flattening groups and skipping empty attributes
+// as slog handlers should
+func addAttr(fields logrus.Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, member := range group {
+			addAttr(fields, prefix, member)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
+
+// logrusLevel maps a slog level to the logrus level covering it; levels below debug map to
+// trace and levels above error stay error
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return logrus.TraceLevel
+	case level < slog.LevelInfo:
+		return logrus.DebugLevel
+	case level < slog.LevelWarn:
+		return logrus.InfoLevel
+	case level < slog.LevelError:
+		return logrus.WarnLevel
+	default:
+		return logrus.ErrorLevel
+	}
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/letusgogo/quick/config"
+)
+
+// maxCapturedBody bounds the captured request and response bodies
+const maxCapturedBody = 64 << 10
+
+// CapturedExchange is a captured request/response pair
+type CapturedExchange struct {
+	Time            time.Time   `json:"time"`
+	ClientIP        string      `json:"client_ip"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeader   http.Header `json:"request_header"`
+	RequestBody     string      `json:"request_body"`
+	Status          int         `json:"status"`
+	ResponseHeader  http.Header `json:"response_header"`
+	ResponseBody    string      `json:"response_body"`
+	LatencyMs       float64     `json:"latency_ms"`
+	BodiesTruncated bool        `json:"bodies_truncated,omitempty"`
+}
+
+// CaptureOption configures CaptureMatching
+type CaptureOption func(*RequestCapture)
+
+// WithRedactedFields redacts headers, query parameters and JSON body fields with these names
+// (case-insensitive, at any depth) in addition to the Authorization, Cookie and Set-Cookie
+// headers and names that look sensitive to config.IsSensitiveKey
+func WithRedactedFields(fields ...string) CaptureOption {
+	return func(rc *RequestCapture) {
+		for _, field := range fields {
+			rc.redacted[strings.ToLower(field)] = true
+		}
+	}
+}
+
+// RequestCapture keeps the most recent request/response pairs of matching requests
+type RequestCapture struct {
+	match    func(*gin.Context) bool
+	redacted map[string]bool
+
+	mu   sync.Mutex
+	buf  []CapturedExchange
+	next int
+	full bool
+}
+
+// CaptureMatching captures full request/response pairs of requests accepted by match, e.g. those
+// of one customer by header or IP, keeping at most max of them. Bodies are truncated to 64KiB.
+// Headers, query parameters and JSON fields named like secrets (token, password, API key...)
+// are redacted. Captures hold request data; restrict access to the handler and redact secrets:
+//
+//	capture := utils.CaptureMatching(func(c *gin.Context) bool {
+//		return c.GetHeader("X-Customer-Id") == "acme"
+//	}, 100, utils.WithRedactedFields("password", "card_number"))
+//	server.GinEngine().Use(capture.Middleware())
+//	server.DebugGroup().GET("/captures", capture.Handler())
+func CaptureMatching(match func(*gin.Context) bool, max int, opts ...CaptureOption) *RequestCapture {
+	if max <= 0 {
+		max = 100
+	}
+	rc := &RequestCapture{
+		match: match,
+		redacted: map[string]bool{
+			"authorization": true,
+			"cookie":        true,
+			"set-cookie":    true,
+		},
+		buf: make([]CapturedExchange, max),
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	return rc
+}
+
+// Middleware captures matching requests, others pass through untouched
+func (rc *RequestCapture) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rc.match(c) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var requestBody []byte
+		if c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxCapturedBody+1))
+			// hand the handler the full body: the captured prefix, then the unread rest
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(requestBody), c.Request.Body), c.Request.Body}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, max: maxCapturedBody}
+		c.Writer = recorder
+		c.Next()
+
+		reqBody, reqTruncated := truncateBody(requestBody)
+		respBody, respTruncated := recorder.body.Bytes(), recorder.truncated
+		rc.add(CapturedExchange{
+			Time:            start,
+			ClientIP:        ClientIP(c),
+			Method:          c.Request.Method,
+			URL:             rc.redactURL(c.Request.URL),
+			RequestHeader:   rc.redactHeader(c.Request.Header),
+			RequestBody:     rc.redactBody(reqBody, reqTruncated),
+			Status:          recorder.Status(),
+			ResponseHeader:  rc.redactHeader(recorder.Header()),
+			ResponseBody:    rc.redactBody(respBody, respTruncated),
+			LatencyMs:       millis(time.Since(start)),
+			BodiesTruncated: reqTruncated || respTruncated,
+		})
+	}
+}
+
+func truncateBody(body []byte) ([]byte, bool) {
+	if len(body) > maxCapturedBody {
+		return body[:maxCapturedBody], true
+	}
+	return body, false
+}
+
+func (rc *RequestCapture) add(exchange CapturedExchange) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.buf[rc.next] = exchange
+	rc.next = (rc.next + 1) % len(rc.buf)
+	if rc.next == 0 {
+		rc.full = true
+	}
+}
+
+// Captures returns the captured exchanges, newest first
+func (rc *RequestCapture) Captures() []CapturedExchange {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	n := rc.next
+	if rc.full {
+		n = len(rc.buf)
+	}
+	captures := make([]CapturedExchange, 0, n)
+	for i := 1; i <= n; i++ {
+		captures = append(captures, rc.buf[(rc.next-i+len(rc.buf))%len(rc.buf)])
+	}
+	return captures
+}
+
+// Handler dumps the captured exchanges as JSON
+func (rc *RequestCapture) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"captures": rc.Captures(),
+		})
+	}
+}
+
+// isRedacted reports whether values of the header, query parameter or field name are redacted
+func (rc *RequestCapture) isRedacted(name string) bool {
+	return rc.redacted[strings.ToLower(name)] || config.IsSensitiveKey(name)
+}
+
+// redactURL returns u with redacted query parameter values, other parameters are kept as sent
+func (rc *RequestCapture) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		rawName, _, hasValue := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if hasValue && rc.isRedacted(name) {
+			params[i] = rawName + "=" + config.RedactedValue
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = strings.Join(params, "&")
+	return redacted.String()
+}
+
+// redactHeader returns a copy of header with redacted values
+func (rc *RequestCapture) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name := range redacted {
+		if rc.isRedacted(name) {
+			redacted[name] = []string{config.RedactedValue}
+		}
+	}
+	return redacted
+}
+
+// redactBody redacts fields of JSON bodies, other bodies are returned as is.
+// Truncated JSON cannot be parsed to redact it, so it is omitted.
+func (rc *RequestCapture) redactBody(body []byte, truncated bool) string {
+	var doc interface{}
+	if len(body) == 0 || json.Unmarshal(body, &doc) != nil {
+		if trimmed := bytes.TrimSpace(body); truncated && len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return "[truncated JSON omitted, it cannot be redacted]"
+		}
+		return string(body)
+	}
+	redacted, err := json.Marshal(rc.redactValue(doc))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func (rc *RequestCapture) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if rc.isRedacted(key) {
+				v[key] = config.RedactedValue
+				continue
+			}
+			v[key] = rc.redactValue(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = rc.redactValue(child)
+		}
+	}
+	return value
+}
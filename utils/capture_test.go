@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCaptureRedactsAndBoundsBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	capture := CaptureMatching(func(c *gin.Context) bool { return true }, 10)
+	engine := gin.New()
+	engine.Use(capture.Middleware())
+	engine.POST("/login", func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=abc")
+		c.String(http.StatusOK, strings.Repeat("x", maxCapturedBody*2))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/login?token=abc123&page=2&api_key=k1", strings.NewReader(`{"user":"alice","password":"hunter2"}`))
+	req.Header.Set("X-Api-Key", "k2")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Body.Len() != maxCapturedBody*2 {
+		t.Errorf("Expected the full response to be sent, got %d bytes", w.Body.Len())
+	}
+
+	captures := capture.Captures()
+	if len(captures) != 1 {
+		t.Fatalf("Expected one capture, got %d", len(captures))
+	}
+	got := captures[0]
+	if got.URL != "/login?token=******&page=2&api_key=******" {
+		t.Errorf("Expected sensitive query parameters to be redacted, got %s", got.URL)
+	}
+	if got.RequestHeader.Get("X-Api-Key") != "******" || got.ResponseHeader.Get("Set-Cookie") != "******" {
+		t.Errorf("Expected sensitive headers to be redacted, got %v and %v", got.RequestHeader, got.ResponseHeader)
+	}
+	if strings.Contains(got.RequestBody, "hunter2") || !strings.Contains(got.RequestBody, "alice") {
+		t.Errorf("Expected only the password to be redacted, got %s", got.RequestBody)
+	}
+	if !got.BodiesTruncated || len(got.ResponseBody) != maxCapturedBody {
+		t.Errorf("Expected the response body to be truncated to %d bytes, got %d", maxCapturedBody, len(got.ResponseBody))
+	}
+}
+
+func TestResponseRecorderStopsAtLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	recorder := &responseRecorder{ResponseWriter: c.Writer, max: 8}
+	recorder.Write([]byte("0123456"))
+	recorder.WriteString("789")
+	recorder.Write([]byte("more"))
+
+	if recorder.body.String() != "01234567" || !recorder.truncated {
+		t.Errorf("Expected 8 recorded bytes and truncation, got %q truncated=%v", recorder.body.String(), recorder.truncated)
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimiter caps the number of in-flight requests with a semaphore
+type ConcurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+	inFlight     atomic.Int64
+}
+
+// NewConcurrencyLimiter creates a limiter allowing max concurrent requests. Requests wait up to
+// queueTimeout for a free slot and are rejected with 503 if none frees up.
+func NewConcurrencyLimiter(max int, queueTimeout time.Duration) *ConcurrencyLimiter {
+	if max <= 0 {
+		max = 1
+	}
+	return &ConcurrencyLimiter{
+		slots:        make(chan struct{}, max),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// ConcurrencyLimit returns a middleware allowing at most max concurrent requests,
+// use NewConcurrencyLimiter to also read the in-flight count
+func ConcurrencyLimit(max int, queueTimeout time.Duration) gin.HandlerFunc {
+	return NewConcurrencyLimiter(max, queueTimeout).Middleware()
+}
+
+// InFlight returns the number of requests currently holding a slot
+func (l *ConcurrencyLimiter) InFlight() int64 {
+	return l.inFlight.Load()
+}
+
+// Middleware returns the gin middleware enforcing the limit
+func (l *ConcurrencyLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.acquire(c) {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error": "too many concurrent requests",
+			})
+			return
+		}
+		defer l.release()
+		c.Next()
+	}
+}
+
+func (l *ConcurrencyLimiter) acquire(c *gin.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		l.inFlight.Add(1)
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) release() {
+	l.inFlight.Add(-1)
+	<-l.slots
+}
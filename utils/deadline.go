@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// grpcTimeoutUnits are the units of the grpc-timeout header format, e.g. "100m" is 100ms
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// DeadlineFromHeader sets the request context deadline from the client's timeout in header
+// (e.g. "X-Request-Timeout"), so handlers and downstream calls using c.Request.Context()
+// honor the client's budget. The timeout is capped at max, which is also used when the header
+// is absent. Values are in grpc-timeout format ("250m" is 250ms, "2S" is 2s) or Go durations
+// ("1.5s", "250ms"); a bare number with a unit is read as grpc-timeout.
+// Invalid or non-positive values are rejected with 400.
+func DeadlineFromHeader(header string, max time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := max
+		if value := c.GetHeader(header); value != "" {
+			parsed, err := parseTimeout(value)
+			if err != nil {
+				JSONError(c, http.StatusBadRequest, fmt.Sprintf("invalid %s header: %v", header, err))
+				return
+			}
+			if parsed < timeout {
+				timeout = parsed
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// maxGRPCTimeoutDigits is the number of digits allowed by the grpc-timeout format
+const maxGRPCTimeoutDigits = 8
+
+// parseTimeout parses a positive grpc-timeout or Go duration value. The grpc-timeout format is
+// tried first, so "250m" is 250ms as sent by grpc clients rather than 250 minutes.
+func parseTimeout(value string) (time.Duration, error) {
+	if d, ok := parseGRPCTimeout(value); ok {
+		return d, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive, got %s", value)
+	}
+	return d, nil
+}
+
+// parseGRPCTimeout parses a grpc-timeout value: up to 8 digits followed by a unit
+func parseGRPCTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > maxGRPCTimeoutDigits+1 {
+		return 0, false
+	}
+	unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	digits := value[:len(value)-1]
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
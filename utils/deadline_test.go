@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"1.5s", 1500 * time.Millisecond, false},
+		{"250ms", 250 * time.Millisecond, false},
+		{"1m30s", 90 * time.Second, false},
+		{"250m", 250 * time.Millisecond, false},
+		{"2S", 2 * time.Second, false},
+		{"1H", time.Hour, false},
+		{"100u", 100 * time.Microsecond, false},
+		{"99999999n", 99999999 * time.Nanosecond, false},
+		{"0s", 0, true},
+		{"0m", 0, true},
+		{"-1s", 0, true},
+		{"123456789S", 0, true},
+		{"abc", 0, true},
+		{"10x", 0, true},
+		{"S", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeout(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Expected %s, got %s (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+// serveWithDeadline sends a request through DeadlineFromHeader capped at max and returns the
+// response and the time left until the handler's context deadline
+func serveWithDeadline(max time.Duration, timeout string) (*httptest.ResponseRecorder, time.Duration, bool) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(DeadlineFromHeader("X-Request-Timeout", max))
+
+	var left time.Duration
+	var called bool
+	engine.GET("/", func(c *gin.Context) {
+		called = true
+		if deadline, ok := c.Request.Context().Deadline(); ok {
+			left = time.Until(deadline)
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if timeout != "" {
+		req.Header.Set("X-Request-Timeout", timeout)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w, left, called
+}
+
+func TestDeadlineFromHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		want    time.Duration
+	}{
+		{"absent header uses max", "", time.Minute},
+		{"shorter timeout", "2s", 2 * time.Second},
+		{"grpc timeout", "500m", 500 * time.Millisecond},
+		{"longer timeout is capped", "1h", time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, left, called := serveWithDeadline(time.Minute, tt.timeout)
+			if !called || w.Code != http.StatusNoContent {
+				t.Fatalf("Expected the handler to run, got %d", w.Code)
+			}
+			if left > tt.want || left < tt.want-time.Second {
+				t.Errorf("Expected about %s left, got %s", tt.want, left)
+			}
+		})
+	}
+}
+
+func TestDeadlineFromHeaderRejectsInvalidValues(t *testing.T) {
+	for _, timeout := range []string{"soon", "-5s", "0", "0s"} {
+		t.Run(timeout, func(t *testing.T) {
+			w, _, called := serveWithDeadline(time.Minute, timeout)
+			if called {
+				t.Error("Expected the handler not to run")
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400, got %d", w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmbeddedOption configures ServeEmbedded
+type EmbeddedOption func(*embeddedOptions)
+
+type embeddedOptions struct {
+	spaFallback  bool
+	indexFile    string
+	cacheControl string
+}
+
+// WithoutSPAFallback returns 404 for unknown paths instead of index.html, for pure static sites
+func WithoutSPAFallback() EmbeddedOption {
+	return func(o *embeddedOptions) {
+		o.spaFallback = false
+	}
+}
+
+// WithIndexFile sets the index file served for directories and SPA fallback (default: index.html)
+func WithIndexFile(name string) EmbeddedOption {
+	return func(o *embeddedOptions) {
+		o.indexFile = name
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of static assets (default: public, max-age=3600).
+// The index file is always served with no-cache so new deployments are picked up.
+func WithCacheControl(value string) EmbeddedOption {
+	return func(o *embeddedOptions) {
+		o.cacheControl = value
+	}
+}
+
+// ServeEmbedded serves files from fsys (typically an embed.FS, use fs.Sub to strip its root dir)
+// under urlPrefix. Unknown paths fall back to index.html for single page apps unless
+// WithoutSPAFallback is given. A "/" prefix serves from gin's NoRoute so API routes keep priority.
+func (h *GinService) ServeEmbedded(urlPrefix string, fsys fs.FS, opts ...EmbeddedOption) {
+	options := &embeddedOptions{
+		spaFallback:  true,
+		indexFile:    "index.html",
+		cacheControl: "public, max-age=3600",
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	prefix := strings.TrimSuffix(urlPrefix, "/")
+	handler := func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(c.Request.URL.Path, prefix)), "/")
+		serveEmbeddedFile(c, fsys, name, options)
+	}
+
+	if prefix == "" {
+		h.ginEngine.NoRoute(handler)
+		return
+	}
+	h.ginEngine.GET(prefix+"/*filepath", handler)
+	h.ginEngine.HEAD(prefix+"/*filepath", handler)
+}
+
+func serveEmbeddedFile(c *gin.Context, fsys fs.FS, name string, options *embeddedOptions) {
+	if name == "" {
+		name = options.indexFile
+	}
+
+	info, err := fs.Stat(fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, options.indexFile)
+		info, err = fs.Stat(fsys, name)
+	}
+	if err != nil {
+		if !options.spaFallback {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		name = options.indexFile
+		if info, err = fs.Stat(fsys, name); err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	if path.Base(name) == options.indexFile {
+		c.Header("Cache-Control", "no-cache")
+	} else {
+		c.Header("Cache-Control", options.cacheControl)
+	}
+	// ServeContent sets Content-Type from the file extension and handles range/conditional requests
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), content)
+}
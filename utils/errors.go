@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+)
+
+// HTTPError is an error carrying the status code and client facing message of the response
+type HTTPError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+// NewHTTPError creates an HTTPError, err is logged but not exposed to the client
+func NewHTTPError(status int, message string, err error) *HTTPError {
+	return &HTTPError{Status: status, Message: message, Err: err}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// JSONError aborts the request with a standard error body: {"error": message}
+func JSONError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
+
+// H adapts a handler returning an error to gin. A returned error or a panic is logged and
+// written with JSONError, with the status mapped from the error:
+//
+//   - *HTTPError: its Status and Message
+//   - validator.ValidationErrors: 400 with the validation message
+//   - context.DeadlineExceeded: 504
+//   - anything else: 500 with a generic message
+//
+// Nothing is written if the handler already wrote a response.
+func H(fn func(c *gin.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := callHandler(c, fn)
+		if err == nil {
+			return
+		}
+
+		status, message := errorResponse(err)
+		c.Error(err)
+		entry := logrus.WithFields(logrus.Fields{
+			"module": "utils",
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"status": status,
+		})
+		if status >= http.StatusInternalServerError {
+			entry.Errorf("Handler failed: %v", err)
+		} else {
+			entry.Debugf("Handler rejected request: %v", err)
+		}
+
+		if c.Writer.Written() {
+			return
+		}
+		JSONError(c, status, message)
+	}
+}
+
+// callHandler runs fn, turning a panic into an error
+func callHandler(c *gin.Context, fn func(c *gin.Context) error) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("handler panic: %v\n%s", e, debug.Stack())
+		}
+	}()
+	return fn(c)
+}
+
+// errorResponse maps err to the response status and client facing message
+func errorResponse(err error) (int, string) {
+	var httpErr *HTTPError
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.Status, httpErr.Message
+	case errors.As(err, &validationErrs):
+		return http.StatusBadRequest, validationErrs.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout)
+	default:
+		return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	}
+}
@@ -3,7 +3,11 @@ package utils
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/letusgogo/quick/utils/listener"
 	"net"
 	"net/http"
 	"time"
@@ -12,20 +16,36 @@ import (
 // GinService 启动一个httpserver 对外提供服务。会依赖各个组件的业务系统
 type GinService struct {
 	local      string
+	reusePort  bool
 	ginEngine  *gin.Engine
 	httpServer *http.Server
 }
 
-func NewGinServer(local string) *GinService {
+// GinOption configures NewGinServer
+type GinOption func(*GinService)
+
+// WithReusePort makes Start listen with SO_REUSEPORT, so several processes can serve the same
+// port with the kernel balancing connections. See listener.Listen for platform caveats.
+func WithReusePort() GinOption {
+	return func(h *GinService) {
+		h.reusePort = true
+	}
+}
+
+func NewGinServer(local string, opts ...GinOption) *GinService {
 	ginEngine := gin.Default()
 
-	return &GinService{
+	h := &GinService{
 		local:     local,
 		ginEngine: ginEngine,
 		httpServer: &http.Server{
 			Handler: ginEngine,
 		},
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 func (h *GinService) GinGroup(relativePath string) *gin.RouterGroup {
@@ -36,15 +56,37 @@ func (h *GinService) GinEngine() *gin.Engine {
 	return h.ginEngine
 }
 
+// DebugGroup returns the /debug route group for diagnostic handlers
+func (h *GinService) DebugGroup() *gin.RouterGroup {
+	return h.ginEngine.Group("/debug")
+}
+
+// RegisterValidation registers a custom validation rule on gin's binding validator,
+// e.g. RegisterValidation("phone", validatePhone) enables `binding:"phone"` tags.
+func (h *GinService) RegisterValidation(tag string, fn validator.Func) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return fmt.Errorf("unsupported binding validator engine: %T", binding.Validator.Engine())
+	}
+	return v.RegisterValidation(tag, fn)
+}
+
 // Start 会阻塞
 func (h *GinService) Start() error {
 	// 设置服务器监听请求端口
-	l, err := net.Listen("tcp4", h.local)
+	l, err := listener.Listen(context.Background(), "tcp4", h.local, h.reusePort)
 	if err != nil {
 		return err
 	}
 
-	err = h.httpServer.Serve(l)
+	return h.ServeListener(l)
+}
+
+// ServeListener serves on an externally provided listener instead of binding local,
+// e.g. a systemd activated socket or an ephemeral listener in tests. It blocks like Start.
+// The server takes ownership of l: it is closed when serving ends or Stop is called.
+func (h *GinService) ServeListener(l net.Listener) error {
+	err := h.httpServer.Serve(l)
 	if errors.Is(err, http.ErrServerClosed) {
 		return nil
 	} else {
@@ -52,13 +94,54 @@ func (h *GinService) Start() error {
 	}
 }
 
+// Stop shuts the server down gracefully, waiting at most waitTime for in-flight requests
 func (h *GinService) Stop(waitTime time.Duration) error {
 	withTimeout, cancelFunc := context.WithTimeout(context.Background(), waitTime)
 	defer cancelFunc()
-	err := h.httpServer.Shutdown(withTimeout)
+	return h.StopContext(withTimeout)
+}
+
+// StopContext shuts the server down gracefully, waiting for in-flight requests until ctx is
+// done, so one shutdown context can bound the server along with other subsystems
+func (h *GinService) StopContext(ctx context.Context) error {
+	return h.httpServer.Shutdown(ctx)
+}
+
+// ServeHTTP passes requests to the gin engine, so the service can be tested with
+// httptest.NewRecorder without binding a port, or with httptest.NewServer(service)
+func (h *GinService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.ginEngine.ServeHTTP(w, r)
+}
+
+// Component adapts the service to app.Component: Start binds the port and serves in the
+// background, Stop shuts the server down gracefully within the context deadline.
+func (h *GinService) Component() *GinComponent {
+	return &GinComponent{service: h, served: make(chan error, 1)}
+}
+
+// GinComponent runs a GinService as an app.Component
+type GinComponent struct {
+	service *GinService
+	served  chan error
+}
+
+// Start binds the service port and serves in the background, a bind error is returned
+func (g *GinComponent) Start(ctx context.Context) error {
+	l, err := listener.Listen(ctx, "tcp4", g.service.local, g.service.reusePort)
 	if err != nil {
 		return err
-	} else {
-		return nil
 	}
+
+	go func() {
+		g.served <- g.service.ServeListener(l)
+	}()
+	return nil
+}
+
+// Stop shuts the server down, waiting for in-flight requests until ctx is done
+func (g *GinComponent) Stop(ctx context.Context) error {
+	if err := g.service.StopContext(ctx); err != nil {
+		return err
+	}
+	return <-g.served
 }
@@ -0,0 +1,122 @@
+// Package grpcmux serves gRPC and HTTP on the same port. It lives in its own package so only
+// apps using it depend on grpc.
+package grpcmux
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+// MultiplexServer routes gRPC requests to a grpc.Server and everything else to a gin engine
+type MultiplexServer struct {
+	local      string
+	grpcServer *grpc.Server
+	httpServer *http.Server
+
+	// mu guards stopping, no gRPC call is added to grpcCalls once it is set
+	mu        sync.Mutex
+	stopping  bool
+	grpcCalls sync.WaitGroup
+}
+
+// NewMultiplexServer creates a server on local routing HTTP/2 requests with an application/grpc
+// content type to grpcServer and all other requests to ginEngine. Cleartext HTTP/2 (h2c) is
+// accepted, so gRPC clients connect without TLS, while HTTP/1.1 clients keep working.
+// gRPC is served through grpc.Server.ServeHTTP, which lacks some grpc transport features
+// (e.g. keepalive enforcement) and is slower than a dedicated gRPC port.
+func NewMultiplexServer(local string, grpcServer *grpc.Server, ginEngine *gin.Engine) *MultiplexServer {
+	s := &MultiplexServer{
+		local:      local,
+		grpcServer: grpcServer,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			s.serveGRPC(w, r)
+			return
+		}
+		ginEngine.ServeHTTP(w, r)
+	})
+	s.httpServer = &http.Server{
+		Handler: h2c.NewHandler(handler, &http2.Server{}),
+	}
+	return s
+}
+
+// serveGRPC serves a gRPC call, tracked so Stop can wait for it. Calls arriving while
+// stopping get 503, which gRPC clients see as Unavailable and may retry elsewhere.
+func (s *MultiplexServer) serveGRPC(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		http.Error(w, "server is stopping", http.StatusServiceUnavailable)
+		return
+	}
+	s.grpcCalls.Add(1)
+	s.mu.Unlock()
+	defer s.grpcCalls.Done()
+
+	s.grpcServer.ServeHTTP(w, r)
+}
+
+// Start listens on local and serves, it blocks until Stop is called
+func (s *MultiplexServer) Start() error {
+	l, err := net.Listen("tcp", s.local)
+	if err != nil {
+		return err
+	}
+	return s.ServeListener(l)
+}
+
+// ServeListener serves on an externally provided listener, it blocks like Start.
+// The server takes ownership of l.
+func (s *MultiplexServer) ServeListener(l net.Listener) error {
+	err := s.httpServer.Serve(l)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Stop stops accepting connections and new gRPC calls, then waits up to waitTime for in-flight
+// HTTP requests and gRPC calls to finish. If they do not, remaining gRPC streams and
+// connections are closed and the context error is returned.
+// h2c hijacks gRPC connections, so http.Server.Shutdown does not see their streams, and
+// grpc.Server.GracefulStop does not support ServeHTTP; Stop therefore tracks gRPC calls itself.
+func (s *MultiplexServer) Stop(waitTime time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), waitTime)
+	defer cancel()
+
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+	grpcDone := make(chan struct{})
+	go func() {
+		s.grpcCalls.Wait()
+		close(grpcDone)
+	}()
+
+	err := s.httpServer.Shutdown(ctx)
+	if err == nil {
+		select {
+		case <-grpcDone:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	if err != nil {
+		// long-lived streams are still open, close them
+		s.grpcServer.Stop()
+		_ = s.httpServer.Close()
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package grpcmux
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestMultiplexServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := NewMultiplexServer(l.Addr().String(), grpcServer, engine)
+	go server.ServeListener(l)
+	defer server.Stop(time.Second)
+
+	resp, err := http.Get("http://" + l.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatalf("HTTP request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Errorf("Expected 'pong', got %q", body)
+	}
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create grpc client: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	check, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("gRPC request failed: %v", err)
+	}
+	if check.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected SERVING, got %v", check.Status)
+	}
+}
+
+// slowHealthServer answers Check once release is closed or the call is cancelled
+type slowHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (h *slowHealthServer) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	close(h.entered)
+	select {
+	case <-h.release:
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startSlowServer serves a slowHealthServer and returns a client connected to it
+func startSlowServer(t *testing.T) (*MultiplexServer, *slowHealthServer, healthpb.HealthClient) {
+	t.Helper()
+	slow := &slowHealthServer{entered: make(chan struct{}), release: make(chan struct{})}
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, slow)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := NewMultiplexServer(l.Addr().String(), grpcServer, gin.New())
+	go server.ServeListener(l)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create grpc client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return server, slow, healthpb.NewHealthClient(conn)
+}
+
+func TestStopWaitsForInFlightGRPC(t *testing.T) {
+	server, slow, client := startSlowServer(t)
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		callErr <- err
+	}()
+	<-slow.entered
+
+	stopErr := make(chan error, 1)
+	go func() {
+		stopErr <- server.Stop(5 * time.Second)
+	}()
+	select {
+	case err := <-stopErr:
+		t.Fatalf("Expected Stop to wait for the in-flight call, returned %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// calls arriving while stopping are rejected
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable for a call while stopping, got %v", err)
+	}
+
+	close(slow.release)
+	if err := <-callErr; err != nil {
+		t.Errorf("Expected the in-flight call to complete, got %v", err)
+	}
+	if err := <-stopErr; err != nil {
+		t.Errorf("Expected Stop to succeed once the call completed, got %v", err)
+	}
+}
+
+func TestStopClosesGRPCAfterTimeout(t *testing.T) {
+	server, slow, client := startSlowServer(t)
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		callErr <- err
+	}()
+	<-slow.entered
+
+	if err := server.Stop(100 * time.Millisecond); err == nil {
+		t.Error("Expected Stop to report the timeout")
+	}
+	select {
+	case err := <-callErr:
+		if err == nil {
+			t.Error("Expected the call to be cancelled")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the call to end after Stop")
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPSMode selects how RequireHTTPS handles plain HTTP requests
+type HTTPSMode int
+
+const (
+	// HTTPSRedirect redirects plain HTTP requests to https with 301
+	HTTPSRedirect HTTPSMode = iota
+	// HTTPSReject rejects plain HTTP requests with 400
+	HTTPSReject
+)
+
+// HTTPSOption configures RequireHTTPS
+type HTTPSOption func(*httpsOptions)
+
+type httpsOptions struct {
+	trustForwardedProto bool
+}
+
+// TrustForwardedProto detects the scheme from the X-Forwarded-Proto header set by a TLS
+// terminating proxy. Only enable it behind a proxy that overwrites the header, otherwise
+// clients can spoof it.
+func TrustForwardedProto() HTTPSOption {
+	return func(o *httpsOptions) {
+		o.trustForwardedProto = true
+	}
+}
+
+// RequireHTTPS returns a middleware that redirects or rejects requests not made over TLS.
+// By default only the connection itself is checked, see TrustForwardedProto.
+func RequireHTTPS(mode HTTPSMode, opts ...HTTPSOption) gin.HandlerFunc {
+	options := &httpsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return func(c *gin.Context) {
+		if isHTTPS(c.Request, options.trustForwardedProto) {
+			c.Next()
+			return
+		}
+
+		if mode == HTTPSReject {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "https required",
+			})
+			return
+		}
+
+		c.Redirect(http.StatusMovedPermanently, "https://"+c.Request.Host+c.Request.URL.RequestURI())
+		c.Abort()
+	}
+}
+
+func isHTTPS(r *http.Request, trustForwardedProto bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !trustForwardedProto {
+		return false
+	}
+	// The first value is the scheme the client used with the outermost proxy
+	proto := strings.TrimSpace(strings.SplitN(r.Header.Get("X-Forwarded-Proto"), ",", 2)[0])
+	return strings.EqualFold(proto, "https")
+}
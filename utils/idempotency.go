@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultIdempotencyMaxSize bounds the request body fingerprinted and the response stored
+const defaultIdempotencyMaxSize = 1 << 20
+
+// CachedResponse is a response stored for an idempotency key
+type CachedResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Fingerprint identifies the request that produced the response, a reused key with a
+	// different fingerprint is rejected
+	Fingerprint string
+}
+
+// IdempotencyStore stores responses by idempotency key. Implementations must be safe for
+// concurrent use; a Redis implementation can map Reserve to SET NX with a TTL.
+type IdempotencyStore interface {
+	// Get returns the completed response stored for key
+	Get(key string) (*CachedResponse, bool, error)
+	// Reserve marks key as in progress, returning false if it is already reserved or completed
+	Reserve(key string) (bool, error)
+	// Save stores the completed response for key
+	Save(key string, resp *CachedResponse) error
+	// Release drops the reservation of key so the request can be retried
+	Release(key string) error
+}
+
+// IdempotencyOption configures Idempotency
+type IdempotencyOption func(*idempotencyOptions)
+
+type idempotencyOptions struct {
+	caller  func(*gin.Context) string
+	maxSize int
+}
+
+// WithIdempotencyCaller identifies the caller owning an idempotency key, e.g. the
+// authenticated user ID, so one caller can never replay the response of another. By default
+// the caller is the Authorization header, or the client IP without one.
+func WithIdempotencyCaller(caller func(*gin.Context) string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.caller = caller
+	}
+}
+
+// WithIdempotencyMaxSize bounds the request body read to fingerprint the request and the
+// response stored for replay, 1MiB by default
+func WithIdempotencyMaxSize(n int) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.maxSize = n
+	}
+}
+
+// defaultIdempotencyCaller identifies the caller by the Authorization header or the client IP
+func defaultIdempotencyCaller(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		return auth
+	}
+	return ClientIP(c)
+}
+
+// Idempotency returns a middleware that runs a request at most once per idempotency key taken
+// from header (e.g. Idempotency-Key). Keys are scoped by caller (see WithIdempotencyCaller).
+// The first response is stored and replayed for later requests with the same key, with an
+// Idempotent-Replayed header; reusing a key for a different method, path, query or body gets
+// 422. A request arriving while the first one is still running gets 409. Server errors (5xx),
+// panics and responses over the size limit are not stored, so the client can retry. Request
+// bodies over the size limit get 413. Requests without the header pass through.
+func Idempotency(store IdempotencyStore, header string, opts ...IdempotencyOption) gin.HandlerFunc {
+	options := &idempotencyOptions{
+		caller:  defaultIdempotencyCaller,
+		maxSize: defaultIdempotencyMaxSize,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(header)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(c.Request.Body, int64(options.maxSize)+1))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+				return
+			}
+			if len(body) > options.maxSize {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large for an idempotent request"})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		fingerprint := requestFingerprint(c.Request, body)
+		callerHash := sha256.Sum256([]byte(options.caller(c)))
+		key := hex.EncodeToString(callerHash[:]) + " " + idempotencyKey
+
+		if resp, ok, err := store.Get(key); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "idempotency store unavailable"})
+			return
+		} else if ok {
+			if resp.Fingerprint != fingerprint {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key was used for a different request"})
+				return
+			}
+			for name, values := range resp.Header {
+				for _, value := range values {
+					c.Writer.Header().Add(name, value)
+				}
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(resp.Status, resp.Header.Get("Content-Type"), resp.Body)
+			c.Abort()
+			return
+		}
+
+		reserved, err := store.Reserve(key)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "idempotency store unavailable"})
+			return
+		}
+		if !reserved {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "request with this idempotency key is in progress"})
+			return
+		}
+
+		// release the reservation unless the response is stored, also when the handler panics
+		saved := false
+		defer func() {
+			if !saved {
+				_ = store.Release(key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, max: options.maxSize}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError || recorder.truncated {
+			return
+		}
+		saved = store.Save(key, &CachedResponse{
+			Status:      recorder.Status(),
+			Header:      recorder.Header().Clone(),
+			Body:        recorder.body.Bytes(),
+			Fingerprint: fingerprint,
+		}) == nil
+	}
+}
+
+// requestFingerprint hashes the method, path, query and body of a request
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + "\n" + r.URL.Path + "\n" + r.URL.RawQuery + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder copies the response body while writing it, keeping at most max bytes
+// (unbounded if max is 0); truncated reports whether anything was dropped
+type responseRecorder struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.record(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+// record copies b into the body up to max
+func (r *responseRecorder) record(b []byte) {
+	if r.max > 0 && r.body.Len()+len(b) > r.max {
+		b = b[:r.max-r.body.Len()]
+		r.truncated = true
+	}
+	r.body.Write(b)
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore for single instance deployments
+type MemoryIdempotencyStore struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+}
+
+type idempotencyEntry struct {
+	resp      *CachedResponse
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory store keeping responses for ttl
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:       ttl,
+		entries:   make(map[string]*idempotencyEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Get(key string) (*CachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.lookup(key)
+	if entry == nil || entry.resp == nil {
+		return nil, false, nil
+	}
+	return entry.resp, true, nil
+}
+
+// Reserve implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Reserve(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lookup(key) != nil {
+		return false, nil
+	}
+	s.sweep()
+	s.entries[key] = &idempotencyEntry{expiresAt: time.Now().Add(s.ttl)}
+	return true, nil
+}
+
+// Save implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Save(key string, resp *CachedResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = &idempotencyEntry{resp: resp, expiresAt: time.Now().Add(s.ttl)}
+	return nil
+}
+
+// Release implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// lookup returns the unexpired entry for key, the caller must hold the lock
+func (s *MemoryIdempotencyStore) lookup(key string) *idempotencyEntry {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil
+	}
+	return entry
+}
+
+// sweep drops expired entries at most once per ttl, so the cost of scanning all entries is
+// spread over the requests of a ttl; the caller must hold the lock
+func (s *MemoryIdempotencyStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotentEngine serves POST /orders through Idempotency, counting handler runs
+func newIdempotentEngine(store IdempotencyStore, handler gin.HandlerFunc, calls *atomic.Int32) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	engine.Use(Idempotency(store, "Idempotency-Key"))
+	engine.POST("/orders", func(c *gin.Context) {
+		calls.Add(1)
+		handler(c)
+	})
+	return engine
+}
+
+// postOrder sends POST /orders with the idempotency key, body and Authorization header
+func postOrder(engine http.Handler, key, body, auth string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Idempotency-Key", key)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotentEngine(NewMemoryIdempotencyStore(time.Minute), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, "created %s", body)
+	}, &calls)
+
+	first := postOrder(engine, "k1", "apple", "alice")
+	second := postOrder(engine, "k1", "apple", "alice")
+	if calls.Load() != 1 {
+		t.Errorf("Expected the handler to run once, ran %d times", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected the first response to be replayed, got %d %q", second.Code, second.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected the replayed response to be marked")
+	}
+
+	if w := postOrder(engine, "k1", "pear", "alice"); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a reused key with a different body, got %d", w.Code)
+	}
+
+	// another caller with the same key gets its own request
+	if w := postOrder(engine, "k1", "apple", "bob"); w.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Expected a different caller not to get a replayed response")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the handler to run for the other caller, ran %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyConcurrentDuplicate(t *testing.T) {
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	engine := newIdempotentEngine(NewMemoryIdempotencyStore(time.Minute), func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.Status(http.StatusOK)
+	}, &calls)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- postOrder(engine, "k1", "", "alice")
+	}()
+	<-entered
+
+	if w := postOrder(engine, "k1", "", "alice"); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the first request runs, got %d", w.Code)
+	}
+	close(release)
+	if w := <-done; w.Code != http.StatusOK {
+		t.Errorf("Expected the first request to succeed, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyExpiry(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotentEngine(NewMemoryIdempotencyStore(50*time.Millisecond), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}, &calls)
+
+	postOrder(engine, "k1", "", "alice")
+	time.Sleep(100 * time.Millisecond)
+	if w := postOrder(engine, "k1", "", "alice"); w.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Expected an expired key not to be replayed")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected the handler to run again after expiry, ran %d times", calls.Load())
+	}
+}
+
+func TestIdempotencyReleasesAfterPanic(t *testing.T) {
+	var calls atomic.Int32
+	engine := newIdempotentEngine(NewMemoryIdempotencyStore(time.Minute), func(c *gin.Context) {
+		if calls.Load() == 1 {
+			panic("boom")
+		}
+		c.Status(http.StatusOK)
+	}, &calls)
+
+	if w := postOrder(engine, "k1", "", "alice"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the panic to be recovered as 500, got %d", w.Code)
+	}
+	if w := postOrder(engine, "k1", "", "alice"); w.Code != http.StatusOK {
+		t.Errorf("Expected the retry to run after the panic, got %d", w.Code)
+	}
+}
+
+func TestIdempotencySkipsLargeResponses(t *testing.T) {
+	var calls atomic.Int32
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Idempotency(NewMemoryIdempotencyStore(time.Minute), "Idempotency-Key", WithIdempotencyMaxSize(8)))
+	engine.POST("/orders", func(c *gin.Context) {
+		calls.Add(1)
+		c.String(http.StatusOK, "a long response")
+	})
+
+	if w := postOrder(engine, "k1", "", "alice"); w.Body.String() != "a long response" {
+		t.Errorf("Expected the full response to be sent, got %q", w.Body.String())
+	}
+	postOrder(engine, "k1", "", "alice")
+	if calls.Load() != 2 {
+		t.Errorf("Expected a response over the limit not to be stored, handler ran %d times", calls.Load())
+	}
+
+	if w := postOrder(engine, "k2", "a body over the limit", "alice"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for a body over the limit, got %d", w.Code)
+	}
+}
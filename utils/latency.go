@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LatencyStats are the latency percentiles of a route over the recent window
+type LatencyStats struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// LatencyTracker keeps a bounded sample of recent request latencies per route.
+// Samples are kept in two windows, the current and the previous one; older samples are dropped,
+// so the percentiles reflect the last one to two windows of traffic. Percentiles are exact
+// nearest-rank values over a fixed ring of samples rather than a t-digest estimate, so under
+// heavy traffic they only cover the most recent samples of each window.
+type LatencyTracker struct {
+	window  time.Duration
+	samples int
+
+	mu       sync.Mutex
+	routes   map[string]*latencyWindow
+	rotateAt time.Time
+}
+
+// latencyWindow holds the samples of a route, each window is a ring buffer of at most samples entries
+type latencyWindow struct {
+	current  []time.Duration
+	previous []time.Duration
+	next     int
+}
+
+// NewLatencyTracker creates a tracker keeping at most samples latencies per route and window
+func NewLatencyTracker(window time.Duration, samples int) *LatencyTracker {
+	if window <= 0 {
+		window = time.Minute
+	}
+	if samples <= 0 {
+		samples = 1024
+	}
+	return &LatencyTracker{
+		window:   window,
+		samples:  samples,
+		routes:   make(map[string]*latencyWindow),
+		rotateAt: time.Now().Add(window),
+	}
+}
+
+// Middleware records the latency of each request under its method and route pattern,
+// so /users/1 and /users/2 share the "GET /users/:id" route. Requests matching no route are
+// all recorded under "unmatched", whatever their method or path.
+func (t *LatencyTracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// unmatched paths and methods are client-controlled, keying by them would grow
+			// one entry per path or method
+			t.add("unmatched", time.Since(start))
+			return
+		}
+		t.add(c.Request.Method+" "+route, time.Since(start))
+	}
+}
+
+func (t *LatencyTracker) add(route string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rotate(time.Now())
+
+	w, ok := t.routes[route]
+	if !ok {
+		w = &latencyWindow{}
+		t.routes[route] = w
+	}
+	if len(w.current) < t.samples {
+		w.current = append(w.current, latency)
+		return
+	}
+	w.current[w.next] = latency
+	w.next = (w.next + 1) % t.samples
+}
+
+// rotate moves the current window to the previous one once the window elapsed.
+// Routes without samples in either window are dropped.
+func (t *LatencyTracker) rotate(now time.Time) {
+	if now.Before(t.rotateAt) {
+		return
+	}
+	// skipping more than one window leaves nothing worth keeping
+	stale := now.Sub(t.rotateAt) >= t.window
+	for route, w := range t.routes {
+		if stale || len(w.current) == 0 {
+			delete(t.routes, route)
+			continue
+		}
+		w.previous, w.current, w.next = w.current, nil, 0
+	}
+	t.rotateAt = now.Add(t.window)
+}
+
+// Percentiles returns the latency stats of each route with recent traffic
+func (t *LatencyTracker) Percentiles() map[string]LatencyStats {
+	t.mu.Lock()
+	t.rotate(time.Now())
+	all := make(map[string][]time.Duration, len(t.routes))
+	for route, w := range t.routes {
+		samples := make([]time.Duration, 0, len(w.current)+len(w.previous))
+		samples = append(samples, w.current...)
+		all[route] = append(samples, w.previous...)
+	}
+	t.mu.Unlock()
+
+	stats := make(map[string]LatencyStats, len(all))
+	for route, samples := range all {
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		stats[route] = LatencyStats{
+			Count: len(samples),
+			P50Ms: millis(quantile(samples, 0.50)),
+			P95Ms: millis(quantile(samples, 0.95)),
+			P99Ms: millis(quantile(samples, 0.99)),
+			MaxMs: millis(samples[len(samples)-1]),
+		}
+	}
+	return stats
+}
+
+// quantile returns the nearest-rank q quantile of the sorted, non-empty samples
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Handler dumps the per-route percentiles as JSON, mount it on the debug group:
+//
+//	tracker := utils.NewLatencyTracker(time.Minute, 1024)
+//	server.GinEngine().Use(tracker.Middleware())
+//	server.DebugGroup().GET("/latency", tracker.Handler())
+func (t *LatencyTracker) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"window_ms": millis(t.window),
+			"routes":    t.Percentiles(),
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLatencyTrackerRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := NewLatencyTracker(time.Minute, 16)
+	engine := gin.New()
+	engine.Use(tracker.Middleware())
+	engine.GET("/users/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, target := range []struct{ method, path string }{
+		{http.MethodGet, "/users/1"},
+		{http.MethodGet, "/users/2"},
+		{http.MethodGet, "/missing"},
+		{"FOO1", "/users/1"},
+		{"FOO2", "/other"},
+	} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(target.method, target.path, nil))
+	}
+
+	stats := tracker.Percentiles()
+	if len(stats) != 2 {
+		t.Errorf("Expected the route and one unmatched entry, got %v", stats)
+	}
+	if stats["GET /users/:id"].Count != 2 {
+		t.Errorf("Expected 2 samples for the route, got %d", stats["GET /users/:id"].Count)
+	}
+	if stats["unmatched"].Count != 3 {
+		t.Errorf("Expected 3 unmatched samples, got %d", stats["unmatched"].Count)
+	}
+}
+
+func TestLatencyTrackerBoundsSamples(t *testing.T) {
+	tracker := NewLatencyTracker(time.Minute, 4)
+	for i := 1; i <= 10; i++ {
+		tracker.add("GET /", time.Duration(i)*time.Millisecond)
+	}
+
+	stats := tracker.Percentiles()["GET /"]
+	if stats.Count != 4 {
+		t.Errorf("Expected the ring to keep 4 samples, got %d", stats.Count)
+	}
+	if stats.P50Ms != 8 || stats.MaxMs != 10 {
+		t.Errorf("Expected p50 8ms and max 10ms over the last samples, got %+v", stats)
+	}
+}
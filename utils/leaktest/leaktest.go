@@ -0,0 +1,82 @@
+// Package leaktest checks tests for leaked goroutines. It lives in its own package so only
+// tests import the testing package, not every binary using utils.
+package leaktest
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leakSettle is how long AssertNoGoroutineLeak waits for goroutines to exit
+const leakSettle = 2 * time.Second
+
+// modulePath identifies the goroutines of this module in stack traces
+const modulePath = "github.com/letusgogo/quick/"
+
+// AssertNoGoroutineLeak fails the test if goroutines running code of this module, started after
+// the call, are still alive when the test ends. Goroutines get a short settle period to exit.
+// Call it first in the test, before starting listeners or IoBind:
+//
+//	func TestServe(t *testing.T) {
+//		leaktest.AssertNoGoroutineLeak(t)
+//		...
+//	}
+//
+// Tests using it must not run in parallel with tests starting goroutines of this module.
+func AssertNoGoroutineLeak(t testing.TB) {
+	t.Helper()
+	before := moduleGoroutines()
+
+	t.Cleanup(func() {
+		var leaked []string
+		deadline := time.Now().Add(leakSettle)
+		for {
+			leaked = leaked[:0]
+			for id, stack := range moduleGoroutines() {
+				if _, ok := before[id]; !ok {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if len(leaked) > 0 {
+			t.Errorf("%d goroutines leaked:\n\n%s", len(leaked), strings.Join(leaked, "\n\n"))
+		}
+	})
+}
+
+// moduleGoroutines returns the stacks of live goroutines running code of this module by goroutine id,
+// except the calling one
+func moduleGoroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	goroutines := make(map[string]string)
+	// the first stack is the calling goroutine
+	for _, stack := range bytes.Split(buf, []byte("\n\n"))[1:] {
+		s := string(stack)
+		if !strings.Contains(s, modulePath) {
+			continue
+		}
+		// other tests running this module's test functions are not leaks
+		if strings.Contains(s, "testing.tRunner") {
+			continue
+		}
+		header, _, _ := strings.Cut(s, " [")
+		goroutines[header] = s
+	}
+	return goroutines
+}
@@ -0,0 +1,53 @@
+package listener
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// ConnHandler handles an accepted connection. ctx carries the ConnInfo of conn and is
+// cancelled when StopGracefully is called or the handler returns.
+type ConnHandler func(ctx context.Context, conn net.Conn)
+
+// ConnInfo describes an accepted connection
+type ConnInfo struct {
+	// ID is unique per listener, starting at 1
+	ID uint64
+	// AcceptedAt is the time the connection was accepted or imported
+	AcceptedAt time.Time
+	// RemoteAddr is the address of the peer
+	RemoteAddr net.Addr
+}
+
+type connInfoKey struct{}
+
+// withConnInfo returns a copy of ctx carrying info
+func withConnInfo(ctx context.Context, info ConnInfo) context.Context {
+	return context.WithValue(ctx, connInfoKey{}, info)
+}
+
+// ConnInfoFromContext returns the ConnInfo of the connection ctx was created for
+func ConnInfoFromContext(ctx context.Context) (ConnInfo, bool) {
+	info, ok := ctx.Value(connInfoKey{}).(ConnInfo)
+	return info, ok
+}
+
+// adaptCallback turns a plain connection callback into a ConnHandler ignoring the context
+func adaptCallback(callback func(conn net.Conn)) ConnHandler {
+	return func(_ context.Context, conn net.Conn) {
+		callback(conn)
+	}
+}
+
+// StartListenCtx is StartListen with a handler receiving a per-connection context, so
+// handlers can log the connection ID and drain once StopGracefully is called. It does not block.
+func (t *TcpListener) StartListenCtx(handler ConnHandler) error {
+	listen, err := Listen(context.Background(), "tcp", t.cfg.Local, t.cfg.ReusePort)
+	if err != nil {
+		return err
+	}
+
+	t.serve(listen, handler)
+	return nil
+}
@@ -1,20 +1,49 @@
 package listener
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net"
 	"runtime/debug"
+	"syscall"
 	"time"
 )
 
-func IoBind(dst io.ReadWriteCloser, src io.ReadWriteCloser) error {
+// BindOption configures IoBind
+type BindOption func(*bindOptions)
+
+type bindOptions struct {
+	keepOpen bool
+}
+
+// KeepOpen keeps the previous IoBind behavior of not closing dst and src,
+// for callers that manage closing themselves
+func KeepOpen() BindOption {
+	return func(o *bindOptions) {
+		o.keepOpen = true
+	}
+}
+
+// IoBind copies data in both directions between dst and src until one direction finishes.
+// Both ends are then closed so the other direction unblocks and its goroutine exits,
+// unless KeepOpen is given. A peer hanging up (see IsConnClosed) is a normal end, not an error.
+//
+// Closing is the default since the other goroutine leaked otherwise; earlier versions left
+// dst and src open. Callers closing or reusing them after IoBind must pass KeepOpen.
+func IoBind(dst io.ReadWriteCloser, src io.ReadWriteCloser, opts ...BindOption) error {
+	options := &bindOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	defer func() {
 		if err := recover(); err != nil {
 			log.Printf("bind crashed %s", err)
 		}
 	}()
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
 		defer func() {
 			if err := recover(); err != nil {
@@ -34,15 +63,48 @@ func IoBind(dst io.ReadWriteCloser, src io.ReadWriteCloser) error {
 		errCh <- err
 	}()
 
-	if err := <-errCh; err != nil && err != io.EOF {
+	err := <-errCh
+	if !options.keepOpen {
+		_ = dst.Close()
+		_ = src.Close()
+		// wait for the other direction to unblock and return; a panic there, e.g. a reused
+		// buffer caught by poisoning, outranks the first direction ending normally
+		var panicErr *copyPanicError
+		if other := <-errCh; errors.As(other, &panicErr) && !errors.As(err, &panicErr) {
+			err = other
+		}
+	}
+
+	if err != nil && err != io.EOF && !IsConnClosed(err) {
 		return err
 	}
 	return nil
 }
 
+// IsConnClosed reports whether err means the connection was closed, by the peer hanging up
+// (broken pipe, connection reset or aborted) or locally, rather than a real failure.
+// Go ignores SIGPIPE for sockets, a write to a closed peer returns EPIPE instead.
+func IsConnClosed(err error) bool {
+	return errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, net.ErrClosed)
+}
+
+// copyPanicError reports a panic while copying, e.g. LeakyBuffer.Get detecting a buffer
+// used after it was returned while buffer poisoning is enabled
+type copyPanicError struct {
+	value interface{}
+}
+
+func (e *copyPanicError) Error() string {
+	return fmt.Sprintf("io copy panicked: %v", e.value)
+}
+
 func ioCopy(dst io.ReadWriter, src io.ReadWriter) (err error) {
 	defer func() {
 		if e := recover(); e != nil {
+			err = &copyPanicError{value: e}
 		}
 	}()
 	buf := LeakyBuffer.Get()
@@ -54,7 +116,7 @@ func ioCopy(dst io.ReadWriter, src io.ReadWriter) (err error) {
 			if n > len(buf) {
 				n = len(buf)
 			}
-			if _, e := dst.Write(buf[0:n]); e != nil {
+			if e := writeFull(dst, buf[0:n]); e != nil {
 				return e
 			}
 		}
@@ -64,6 +126,21 @@ func ioCopy(dst io.ReadWriter, src io.ReadWriter) (err error) {
 	}
 }
 
+// writeFull writes all of b to dst, retrying on short writes
+func writeFull(dst io.Writer, b []byte) error {
+	for len(b) > 0 {
+		n, err := dst.Write(b)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return io.ErrShortWrite
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
 func Close(conn net.Conn) {
 	_ = conn.SetDeadline(time.Now().Add(time.Millisecond * 100))
 	if err := conn.Close(); err != nil {
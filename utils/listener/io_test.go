@@ -0,0 +1,157 @@
+package listener
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shortWriter writes at most max bytes per Write call without returning an error
+type shortWriter struct {
+	bytes.Buffer
+	max int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		p = p[:w.max]
+	}
+	return w.Buffer.Write(p)
+}
+
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+func TestIoCopyHandlesShortWrites(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	dst := &shortWriter{max: 7}
+	src := readWriter{Reader: bytes.NewReader(data)}
+
+	if err := ioCopy(readWriter{Writer: dst}, src); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+	if !bytes.Equal(dst.Bytes(), data) {
+		t.Errorf("Expected %d bytes to be copied intact, got %d bytes", len(data), dst.Len())
+	}
+}
+
+func TestBufferPoisoning(t *testing.T) {
+	SetBufferPoisoning(true)
+	defer SetBufferPoisoning(false)
+
+	lb := NewLeakyBuf(1, 16)
+	b := lb.Get()
+	copy(b, "secret")
+	lb.Put(b)
+	if bytes.Contains(b, []byte("secret")) {
+		t.Errorf("Expected buffer to be poisoned on Put, got %q", b)
+	}
+
+	// a retained slice written after Put is reported by the next Get
+	b[0] = 'x'
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Get to panic on a buffer modified after Put")
+		}
+	}()
+	lb.Get()
+}
+
+func TestIoBindReportsPoisonedBuffer(t *testing.T) {
+	SetBufferPoisoning(true)
+	defer SetBufferPoisoning(false)
+
+	// a buffer written after being returned to the pool
+	b := LeakyBuffer.Get()
+	LeakyBuffer.Put(b)
+	b[0] = 'x'
+
+	left, leftPeer := net.Pipe()
+	right, rightPeer := net.Pipe()
+	defer leftPeer.Close()
+	defer rightPeer.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- IoBind(left, right)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "leaky buffer modified after Put") {
+			t.Errorf("Expected IoBind to report the poisoned buffer, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("IoBind did not return after the poisoned buffer")
+	}
+}
+
+// resetPair returns a server side connection whose peer was reset with an RST
+func resetPair(t *testing.T) net.Conn {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	server, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Failed to accept: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	// closing with linger 0 sends an RST instead of a FIN
+	client.(*net.TCPConn).SetLinger(0)
+	client.Close()
+	time.Sleep(50 * time.Millisecond)
+	return server
+}
+
+func TestIsConnClosedOnResetPeer(t *testing.T) {
+	server := resetPair(t)
+
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		_, err = server.Write([]byte("hello"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err == nil {
+		t.Fatalf("Expected write to a reset peer to fail")
+	}
+	if !IsConnClosed(err) {
+		t.Errorf("Expected %v to be reported as a closed connection", err)
+	}
+	if IsConnClosed(io.ErrUnexpectedEOF) {
+		t.Errorf("Expected unrelated errors not to be reported as a closed connection")
+	}
+}
+
+func TestIoBindResetPeerIsNotAnError(t *testing.T) {
+	server := resetPair(t)
+	other, peer := net.Pipe()
+	defer peer.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- IoBind(server, other)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected reset peer to end IoBind without error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("IoBind did not return after the peer reset")
+	}
+}
@@ -0,0 +1,60 @@
+package listener_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/letusgogo/quick/utils/leaktest"
+	"github.com/letusgogo/quick/utils/listener"
+)
+
+func TestIoBindNoGoroutineLeak(t *testing.T) {
+	leaktest.AssertNoGoroutineLeak(t)
+
+	left, leftPeer := net.Pipe()
+	right, rightPeer := net.Pipe()
+	defer rightPeer.Close()
+	go io.Copy(io.Discard, rightPeer)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- listener.IoBind(left, right)
+	}()
+
+	leftPeer.Write([]byte("hello"))
+	leftPeer.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("IoBind did not return after the peer closed")
+	}
+}
+
+func TestStopGracefullyNoGoroutineLeak(t *testing.T) {
+	leaktest.AssertNoGoroutineLeak(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	tcpListener := listener.NewTcpListener(&listener.TcpListenerArgs{})
+	if err := tcpListener.StartListenOn(l, func(conn net.Conn) {
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	conn.Write([]byte("hello"))
+	conn.Close()
+
+	if err := tcpListener.StopGracefully(time.Second); err != nil {
+		t.Errorf("Failed to stop listener: %v", err)
+	}
+}
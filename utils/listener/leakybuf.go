@@ -1,6 +1,29 @@
 // Provides leaky buffer, based on the example in Effective Go.
 package listener
 
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// poisonByte fills pooled buffers while buffer poisoning is enabled
+const poisonByte = 0xDB
+
+var bufferPoisoning atomic.Bool
+
+// SetBufferPoisoning enables a debug mode catching code that keeps using a buffer after it was
+// returned to a leaky buffer, e.g. a slice of the IoBind buffer retained beyond the callback.
+// Put fills buffers with a sentinel pattern, so stale reads see garbage instead of plausible data,
+// and Get panics if a pooled buffer was written to after Put. Enable it before buffers are used,
+// e.g. in TestMain; enabling drains LeakyBuffer so buffers put earlier are not reported.
+// It costs a fill and a scan per buffer, so keep it off in production.
+func SetBufferPoisoning(enabled bool) {
+	if enabled && !bufferPoisoning.Load() {
+		LeakyBuffer.drain()
+	}
+	bufferPoisoning.Store(enabled)
+}
+
 type LeakyBuf struct {
 	bufSize  int // size of each buffer
 	freeList chan []byte
@@ -24,6 +47,9 @@ func NewLeakyBuf(n, bufSize int) *LeakyBuf {
 func (lb *LeakyBuf) Get() (b []byte) {
 	select {
 	case b = <-lb.freeList:
+		if bufferPoisoning.Load() && len(bytes.Trim(b, string([]byte{poisonByte}))) != 0 {
+			panic("leaky buffer modified after Put, a buffer is still used after it was returned")
+		}
 	default:
 		b = make([]byte, lb.bufSize)
 	}
@@ -37,9 +63,25 @@ func (lb *LeakyBuf) Put(b []byte) {
 	if len(b) != lb.bufSize {
 		panic("invalid buffer size that's put into leaky buffer")
 	}
+	if bufferPoisoning.Load() {
+		for i := range b {
+			b[i] = poisonByte
+		}
+	}
 	select {
 	case lb.freeList <- b:
 	default:
 	}
 	return
 }
+
+// drain drops all pooled buffers
+func (lb *LeakyBuf) drain() {
+	for {
+		select {
+		case <-lb.freeList:
+		default:
+			return
+		}
+	}
+}
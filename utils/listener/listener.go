@@ -1,16 +1,27 @@
 package listener
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"net"
+	"os"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
 type TcpListenerArgs struct {
 	Local string // 本地使用的地址
+	// KeepAlive enables TCP keep-alive with the given period on accepted connections.
+	// Zero leaves the connection untouched. Only applies to *net.TCPConn, so a listener
+	// wrapped by TLS must apply it on the underlying connection itself.
+	KeepAlive time.Duration
+	// ReusePort sets SO_REUSEPORT so several processes can share Local, see Listen
+	ReusePort bool
 }
 
 // TcpListener tcp 服务器
@@ -19,23 +30,71 @@ type TcpListener struct {
 	quitChan chan interface{}
 	wg       sync.WaitGroup
 	Listener net.Listener
+
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+
+	// ctx is the parent of all connection contexts, cancelled by StopGracefully
+	ctx        context.Context
+	cancel     context.CancelFunc
+	nextConnID atomic.Uint64
 }
 
 func NewTcpListener(cfg *TcpListenerArgs) *TcpListener {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &TcpListener{
 		cfg:      cfg,
 		quitChan: make(chan interface{}),
+		conns:    make(map[net.Conn]struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
 	}
 }
 
 // StartListen start tcp server. Notice: this method will not block
 // callback will be called when new connection accepted
 func (t *TcpListener) StartListen(callback func(conn net.Conn)) error {
-	listen, err := net.Listen("tcp", t.cfg.Local)
+	listen, err := Listen(context.Background(), "tcp", t.cfg.Local, t.cfg.ReusePort)
 	if err != nil {
 		return err
 	}
 
+	t.serve(listen, adaptCallback(callback))
+	return nil
+}
+
+// StartListenFD starts the tcp server on an already bound listening socket, e.g. one passed
+// by systemd socket activation (fd 3). The listener takes ownership of fd: it is closed once
+// wrapped, and the resulting listener is closed by StopGracefully. Like StartListen, it does not block.
+func (t *TcpListener) StartListenFD(fd uintptr, callback func(conn net.Conn)) error {
+	f := os.NewFile(fd, "listener")
+	if f == nil {
+		return fmt.Errorf("invalid listener fd: %d", fd)
+	}
+	// FileListener dups the fd, so the original can be closed right away
+	defer f.Close()
+
+	listen, err := net.FileListener(f)
+	if err != nil {
+		return err
+	}
+
+	t.serve(listen, adaptCallback(callback))
+	return nil
+}
+
+// StartListenOn starts the tcp server on an externally provided listener, e.g. an ephemeral
+// listener in tests. The listener takes ownership of l and closes it in StopGracefully.
+func (t *TcpListener) StartListenOn(l net.Listener, callback func(conn net.Conn)) error {
+	if l == nil {
+		return errors.New("nil listener")
+	}
+	t.serve(l, adaptCallback(callback))
+	return nil
+}
+
+// serve accepts connections on listen in a new goroutine until StopGracefully is called
+func (t *TcpListener) serve(listen net.Listener, handler ConnHandler) {
 	t.Listener = listen
 
 	t.wg.Add(1)
@@ -49,34 +108,129 @@ func (t *TcpListener) StartListen(callback func(conn net.Conn)) error {
 				case <-t.quitChan:
 					return
 				default:
+					// a client hanging up before accept completes does not affect the listener
+					if errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.ECONNRESET) {
+						continue
+					}
 					log.Printf("TcpListener accept error: %v", err.Error())
 					return
 				}
 			} else {
-				t.wg.Add(1)
-				go func() {
-					defer t.wg.Done()
-					defer func() {
-						if e := recover(); e != nil {
-							log.Printf("TcpListener connection handler crashed , acceptError : %v , \ntrace:%v", e, string(debug.Stack()))
-						}
-					}()
-					// accept new connection, callback
-					callback(conn)
-				}()
+				t.setKeepAlive(conn)
+				t.handle(conn, handler)
 			}
 		}
 	}()
+}
 
-	return nil
+// handle runs handler for conn in a new goroutine, tracking conn until handler returns
+func (t *TcpListener) handle(conn net.Conn, handler ConnHandler) {
+	ctx, cancel := context.WithCancel(withConnInfo(t.ctx, ConnInfo{
+		ID:         t.nextConnID.Add(1),
+		AcceptedAt: time.Now(),
+		RemoteAddr: conn.RemoteAddr(),
+	}))
+
+	t.connsMu.Lock()
+	t.conns[conn] = struct{}{}
+	t.connsMu.Unlock()
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer cancel()
+		defer func() {
+			t.connsMu.Lock()
+			delete(t.conns, conn)
+			t.connsMu.Unlock()
+		}()
+		defer func() {
+			if e := recover(); e != nil {
+				// a handler panicking on a write to a peer that hung up is not a crash
+				if err, ok := e.(error); ok && IsConnClosed(err) {
+					return
+				}
+				log.Printf("TcpListener connection handler crashed , acceptError : %v , \ntrace:%v", e, string(debug.Stack()))
+			}
+		}()
+		// accept new connection, callback
+		handler(ctx, conn)
+	}()
+}
+
+// ExportConns returns duplicated fds of the connections currently being handled, for handing
+// them off to a successor process during a binary upgrade, e.g. via exec.Cmd.ExtraFiles.
+// The socket stays open as long as any fd refers to it, so the old handlers may close their
+// conns afterwards without dropping the client; they must not write to them anymore though.
+// Connections that are not tcp or fail to dup are skipped and logged.
+func (t *TcpListener) ExportConns() []*os.File {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+
+	files := make([]*os.File, 0, len(t.conns))
+	for conn := range t.conns {
+		tcpConn, ok := conn.(*net.TCPConn)
+		if !ok {
+			log.Printf("TcpListener export skipped non tcp connection: %v", conn.RemoteAddr())
+			continue
+		}
+		f, err := tcpConn.File()
+		if err != nil {
+			log.Printf("TcpListener export connection %v err: %v", conn.RemoteAddr(), err)
+			continue
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+// ImportConns resumes connections handed off by a predecessor's ExportConns, e.g. the
+// successor's os.NewFile(3+i, "conn") for each of the inherited ExtraFiles. Each conn is
+// passed to callback and tracked like an accepted one, so StopGracefully waits for it.
+// The files are closed once wrapped.
+func (t *TcpListener) ImportConns(files []*os.File, callback func(conn net.Conn)) error {
+	var errs []error
+	for _, f := range files {
+		conn, err := net.FileConn(f)
+		f.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("import connection %s: %w", f.Name(), err))
+			continue
+		}
+		t.setKeepAlive(conn)
+		t.handle(conn, adaptCallback(callback))
+	}
+	return errors.Join(errs...)
+}
+
+// setKeepAlive applies the configured keep-alive period to tcp connections
+func (t *TcpListener) setKeepAlive(conn net.Conn) {
+	if t.cfg.KeepAlive <= 0 {
+		return
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	if err := tcpConn.SetKeepAlive(true); err != nil {
+		log.Printf("TcpListener set keep alive error: %v", err)
+		return
+	}
+	if err := tcpConn.SetKeepAlivePeriod(t.cfg.KeepAlive); err != nil {
+		log.Printf("TcpListener set keep alive period error: %v", err)
+	}
 }
 
 func (t *TcpListener) StopGracefully(wait time.Duration) error {
 	close(t.quitChan)
+	// tell handlers to finish up, they are still waited for below
+	t.cancel()
 
-	err := t.Listener.Close()
-	if err != nil {
-		log.Printf("TcpListener close tcp listener err: %v", err)
+	// a successor may only resume imported connections without listening itself
+	if t.Listener != nil {
+		if err := t.Listener.Close(); err != nil {
+			log.Printf("TcpListener close tcp listener err: %v", err)
+		}
 	}
 	allExitChan := make(chan bool)
 	go func() {
@@ -92,3 +246,32 @@ func (t *TcpListener) StopGracefully(wait time.Duration) error {
 		return nil
 	}
 }
+
+// defaultComponentStopWait bounds StopGracefully when the stop context has no deadline
+const defaultComponentStopWait = 30 * time.Second
+
+// Component adapts the listener to app.Component: Start listens with callback, Stop stops
+// gracefully within the context deadline.
+func (t *TcpListener) Component(callback func(conn net.Conn)) *ListenerComponent {
+	return &ListenerComponent{listener: t, callback: callback}
+}
+
+// ListenerComponent runs a TcpListener as an app.Component
+type ListenerComponent struct {
+	listener *TcpListener
+	callback func(conn net.Conn)
+}
+
+// Start listens on the configured address, it does not block
+func (l *ListenerComponent) Start(ctx context.Context) error {
+	return l.listener.StartListen(l.callback)
+}
+
+// Stop closes the listener and waits for connection handlers until ctx is done
+func (l *ListenerComponent) Stop(ctx context.Context) error {
+	wait := defaultComponentStopWait
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = time.Until(deadline)
+	}
+	return l.listener.StopGracefully(wait)
+}
@@ -0,0 +1,125 @@
+package listener
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestExportImportConns(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	accepted := make(chan struct{})
+	release := make(chan struct{})
+	old := NewTcpListener(&TcpListenerArgs{})
+	if err := old.StartListenOn(l, func(conn net.Conn) {
+		close(accepted)
+		<-release
+		conn.Close()
+	}); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+	<-accepted
+
+	files := old.ExportConns()
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 exported connection, got %d", len(files))
+	}
+	// the old handler closes its conn, the exported fd keeps the socket open
+	close(release)
+	if err := old.StopGracefully(time.Second); err != nil {
+		t.Fatalf("Failed to stop old listener: %v", err)
+	}
+
+	successor := NewTcpListener(&TcpListenerArgs{})
+	if err := successor.ImportConns(files, func(conn net.Conn) {
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		conn.Write([]byte("echo " + line))
+	}); err != nil {
+		t.Fatalf("Failed to import connections: %v", err)
+	}
+
+	client.SetDeadline(time.Now().Add(time.Second))
+	if _, err := client.Write([]byte("ping\n")); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	reply, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil || reply != "echo ping\n" {
+		t.Errorf("Expected echo from successor, got %q (%v)", reply, err)
+	}
+
+	if err := successor.StopGracefully(time.Second); err != nil {
+		t.Errorf("Failed to stop successor: %v", err)
+	}
+}
+
+func TestListenReusePort(t *testing.T) {
+	first, err := Listen(context.Background(), "tcp", "127.0.0.1:0", true)
+	if errors.Is(err, ErrReusePortUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer first.Close()
+
+	second, err := Listen(context.Background(), "tcp", first.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("Expected a second listener on the same port, got %v", err)
+	}
+	second.Close()
+
+	plain, err := Listen(context.Background(), "tcp", first.Addr().String(), false)
+	if err == nil {
+		plain.Close()
+		t.Errorf("Expected listening without SO_REUSEPORT on a shared port to fail")
+	}
+}
+
+func TestStartListenCtx(t *testing.T) {
+	tl := NewTcpListener(&TcpListenerArgs{Local: "127.0.0.1:0"})
+	infos := make(chan ConnInfo, 1)
+	if err := tl.StartListenCtx(func(ctx context.Context, conn net.Conn) {
+		defer conn.Close()
+		info, _ := ConnInfoFromContext(ctx)
+		infos <- info
+		// drain until the listener stops
+		<-ctx.Done()
+	}); err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+
+	client, err := net.Dial("tcp", tl.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	info := <-infos
+	if info.ID != 1 {
+		t.Errorf("Expected connection ID 1, got %d", info.ID)
+	}
+	if info.RemoteAddr.String() != client.LocalAddr().String() {
+		t.Errorf("Expected remote addr %s, got %s", client.LocalAddr(), info.RemoteAddr)
+	}
+	if info.AcceptedAt.IsZero() {
+		t.Error("Expected accept time to be set")
+	}
+
+	if err := tl.StopGracefully(time.Second); err != nil {
+		t.Errorf("Expected handler to return on stop, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"net"
+	"runtime"
+)
+
+// ErrReusePortUnsupported is returned when SO_REUSEPORT is requested on a platform without it
+var ErrReusePortUnsupported = errors.New("SO_REUSEPORT is not supported on " + runtime.GOOS)
+
+// Listen binds network and address like net.Listen, with SO_REUSEPORT set when reusePort is true,
+// so several processes can listen on the same port and the kernel balances connections
+// between them. It is supported on Linux and the BSDs (including macOS) and returns
+// ErrReusePortUnsupported elsewhere. Caveats: on Linux all processes must run as the same
+// user, and the kernel hashes connections to listeners, so a process stopping drops
+// the connections queued in its accept backlog. On macOS and the BSDs (except FreeBSD 12+
+// with SO_REUSEPORT_LB) connections are not balanced, typically the last listener gets them all.
+func Listen(ctx context.Context, network, address string, reusePort bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reusePort {
+		if !reusePortSupported {
+			return nil, ErrReusePortUnsupported
+		}
+		lc.Control = setReusePort
+	}
+	return lc.Listen(ctx, network, address)
+}
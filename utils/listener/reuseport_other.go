@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package listener
+
+import (
+	"syscall"
+)
+
+// reusePortSupported reports whether SO_REUSEPORT can be set on this platform
+const reusePortSupported = false
+
+func setReusePort(network, address string, c syscall.RawConn) error {
+	return ErrReusePortUnsupported
+}
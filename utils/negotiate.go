@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+)
+
+// negotiatedTypeKey is the gin context key of the content type selected by Negotiate
+const negotiatedTypeKey = "utils.negotiatedType"
+
+// Content types supported by Render
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEYAML     = "application/yaml"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEProtobuf = "application/x-protobuf"
+	MIMEPlain    = "text/plain"
+)
+
+// mediaRange is one entry of an Accept header
+type mediaRange struct {
+	mainType, subType string
+	q                 float64
+}
+
+// Negotiate returns a middleware selecting the response content type from the offered ones
+// by the Accept header, honoring q-values and wildcards. The selected type is read with
+// NegotiatedType and used by Render. A missing Accept header selects the first offered type,
+// if no offered type is acceptable the request is rejected with 406.
+func Negotiate(offered []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		contentType := negotiate(c.GetHeader("Accept"), offered)
+		if contentType == "" {
+			JSONError(c, http.StatusNotAcceptable, "not acceptable, supported types: "+strings.Join(offered, ", "))
+			return
+		}
+		c.Set(negotiatedTypeKey, contentType)
+		c.Next()
+	}
+}
+
+// NegotiatedType returns the content type selected by Negotiate, or "" without the middleware
+func NegotiatedType(c *gin.Context) string {
+	return c.GetString(negotiatedTypeKey)
+}
+
+// Render writes data with status 200 in the content type selected by Negotiate, JSON if the
+// middleware did not run. Protobuf requires data to be a proto.Message.
+func Render(c *gin.Context, data interface{}) {
+	switch contentType := NegotiatedType(c); contentType {
+	case "", MIMEJSON:
+		c.JSON(http.StatusOK, data)
+	case MIMEXML, "text/xml":
+		c.XML(http.StatusOK, data)
+	case MIMEYAML, "application/x-yaml":
+		c.YAML(http.StatusOK, data)
+	case MIMEMsgPack, "application/x-msgpack":
+		c.Render(http.StatusOK, render.MsgPack{Data: data})
+	case MIMEProtobuf, "application/protobuf":
+		if _, ok := data.(proto.Message); !ok {
+			JSONError(c, http.StatusInternalServerError, fmt.Sprintf("cannot render %T as protobuf", data))
+			return
+		}
+		c.ProtoBuf(http.StatusOK, data)
+	case MIMEPlain:
+		c.String(http.StatusOK, "%v", data)
+	default:
+		JSONError(c, http.StatusInternalServerError, "unsupported content type: "+contentType)
+	}
+}
+
+// negotiate returns the offered type with the highest q-value in accept, the earlier offered
+// type on ties, or "" if none is acceptable
+func negotiate(accept string, offered []string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offered[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, contentType := range offered {
+		if q := acceptQuality(ranges, contentType); q > bestQ {
+			best, bestQ = contentType, q
+		}
+	}
+	return best
+}
+
+// parseAccept parses the media ranges of an Accept header, skipping malformed ones
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		mainType, subType, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mainType: mainType, subType: subType, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the q-value of the most specific range matching contentType, 0 if none
+func acceptQuality(ranges []mediaRange, contentType string) float64 {
+	mainType, subType, _ := strings.Cut(strings.ToLower(contentType), "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.mainType == mainType && r.subType == subType:
+			s = 2
+		case r.mainType == mainType && r.subType == "*":
+			s = 1
+		case r.mainType == "*" && r.subType == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestNegotiate(t *testing.T) {
+	offered := []string{MIMEJSON, MIMEXML, MIMEYAML}
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"missing header selects first offered", "", MIMEJSON},
+		{"exact match", "application/xml", MIMEXML},
+		{"highest q-value wins", "application/json;q=0.5, application/yaml;q=0.9", MIMEYAML},
+		{"tie keeps offered order", "application/yaml, application/xml", MIMEXML},
+		{"subtype wildcard", "application/*", MIMEJSON},
+		{"full wildcard", "*/*", MIMEJSON},
+		{"specific range beats wildcard", "*/*;q=0.1, application/xml;q=0.8", MIMEXML},
+		{"q=0 excludes a type", "application/json;q=0, */*", MIMEXML},
+		{"case insensitive", "Application/XML", MIMEXML},
+		{"malformed ranges are skipped", "application/xml;q=abc, ;;, application/yaml", MIMEYAML},
+		{"out of range q is skipped", "application/xml;q=2, application/yaml;q=0.5", MIMEYAML},
+		{"nothing acceptable", "text/html", ""},
+		{"only malformed ranges", "garbage", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiate(tt.accept, offered); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// serveNegotiated sends GET / with the Accept header through Negotiate and Render of data
+func serveNegotiated(offered []string, accept string, data interface{}) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	if offered != nil {
+		engine.Use(Negotiate(offered))
+	}
+	engine.GET("/", func(c *gin.Context) {
+		Render(c, data)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+type negotiateItem struct {
+	Name string `json:"name" xml:"name" yaml:"name"`
+}
+
+func TestRender(t *testing.T) {
+	offered := []string{MIMEJSON, MIMEXML, MIMEYAML, MIMEMsgPack, MIMEPlain}
+	item := negotiateItem{Name: "apple"}
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"application/json", MIMEJSON, `{"name":"apple"}`},
+		{"application/xml", MIMEXML, "<name>apple</name>"},
+		{"application/yaml", MIMEYAML, "name: apple"},
+		{"application/msgpack", MIMEMsgPack, "apple"},
+		{"text/plain", MIMEPlain, "{apple}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			w := serveNegotiated(offered, tt.accept, item)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("Expected content type %s, got %s", tt.contentType, w.Header().Get("Content-Type"))
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("Expected body to contain %q, got %q", tt.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRenderWithoutNegotiateWritesJSON(t *testing.T) {
+	w := serveNegotiated(nil, "application/xml", negotiateItem{Name: "apple"})
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), MIMEJSON) || w.Body.String() != `{"name":"apple"}` {
+		t.Errorf("Expected JSON without the middleware, got %s %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	w := serveNegotiated([]string{MIMEJSON}, "text/html", negotiateItem{})
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("Expected 406, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), MIMEJSON) {
+		t.Errorf("Expected the supported types in the error, got %q", w.Body.String())
+	}
+}
+
+func TestRenderProtobuf(t *testing.T) {
+	offered := []string{MIMEProtobuf}
+	w := serveNegotiated(offered, MIMEProtobuf, wrapperspb.String("apple"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var decoded wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &decoded); err != nil || decoded.GetValue() != "apple" {
+		t.Errorf("Expected a protobuf encoded value, got %q (%v)", decoded.GetValue(), err)
+	}
+
+	w = serveNegotiated(offered, MIMEProtobuf, negotiateItem{Name: "apple"})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500 for a non-proto value, got %d", w.Code)
+	}
+}
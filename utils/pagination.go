@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageDefaults configures ParsePagination
+type PageDefaults struct {
+	// PageSize is used when page_size is absent, default 20
+	PageSize int
+	// MaxPageSize bounds page_size, default 100
+	MaxPageSize int
+}
+
+// Page is a requested page, Number starts at 1
+type Page struct {
+	Number int `json:"page"`
+	Size   int `json:"page_size"`
+}
+
+// Offset returns the number of items before the page, e.g. for SQL OFFSET
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Size
+}
+
+// Limit returns the page size, e.g. for SQL LIMIT
+func (p Page) Limit() int {
+	return p.Size
+}
+
+// ParsePagination parses the ?page=&page_size= query parameters. page starts at 1 and defaults
+// to 1, page_size defaults to defaults.PageSize and must not exceed defaults.MaxPageSize.
+// Invalid values return an *HTTPError with status 400, so handlers wrapped by H can return it.
+func ParsePagination(c *gin.Context, defaults PageDefaults) (Page, error) {
+	if defaults.PageSize <= 0 {
+		defaults.PageSize = 20
+	}
+	if defaults.MaxPageSize <= 0 {
+		defaults.MaxPageSize = 100
+	}
+
+	page := Page{Number: 1, Size: defaults.PageSize}
+	if value := c.Query("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return Page{}, NewHTTPError(http.StatusBadRequest, "page must be a positive integer", err)
+		}
+		page.Number = n
+	}
+	if value := c.Query("page_size"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > defaults.MaxPageSize {
+			message := fmt.Sprintf("page_size must be between 1 and %d", defaults.MaxPageSize)
+			return Page{}, NewHTTPError(http.StatusBadRequest, message, err)
+		}
+		page.Size = n
+	}
+	if page.Number-1 > math.MaxInt/page.Size {
+		return Page{}, NewHTTPError(http.StatusBadRequest, "page is out of range", nil)
+	}
+	return page, nil
+}
+
+// PaginatedResponse writes items of page with status 200 in the standard envelope:
+//
+//	{"items": [...], "total": 42, "page": 2, "page_size": 20, "has_next": true}
+//
+// items is written as [] rather than null when empty.
+func PaginatedResponse[T any](c *gin.Context, items []T, total int64, page Page) {
+	if items == nil {
+		items = []T{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"items":     items,
+		"total":     total,
+		"page":      page.Number,
+		"page_size": page.Size,
+		"has_next":  int64(page.Offset()+len(items)) < total,
+	})
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newPaginationContext returns a gin context for GET /items with the query
+func newPaginationContext(query string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+	return c, w
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		defaults PageDefaults
+		want     Page
+	}{
+		{"defaults", "", PageDefaults{}, Page{Number: 1, Size: 20}},
+		{"custom defaults", "", PageDefaults{PageSize: 50, MaxPageSize: 200}, Page{Number: 1, Size: 50}},
+		{"explicit values", "page=3&page_size=10", PageDefaults{}, Page{Number: 3, Size: 10}},
+		{"max page size", "page_size=100", PageDefaults{}, Page{Number: 1, Size: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newPaginationContext(tt.query)
+			page, err := ParsePagination(c, tt.defaults)
+			if err != nil {
+				t.Fatalf("Failed to parse pagination: %v", err)
+			}
+			if page != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, page)
+			}
+		})
+	}
+}
+
+func TestParsePaginationRejectsInvalidValues(t *testing.T) {
+	for _, query := range []string{
+		"page=0",
+		"page=-1",
+		"page=abc",
+		"page_size=0",
+		"page_size=101",
+		"page_size=ten",
+		"page=9223372036854775807&page_size=100",
+	} {
+		t.Run(query, func(t *testing.T) {
+			c, _ := newPaginationContext(query)
+			_, err := ParsePagination(c, PageDefaults{})
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.Status != http.StatusBadRequest {
+				t.Errorf("Expected a 400 HTTPError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPageOffset(t *testing.T) {
+	page := Page{Number: 3, Size: 20}
+	if page.Offset() != 40 || page.Limit() != 20 {
+		t.Errorf("Expected offset 40 and limit 20, got %d and %d", page.Offset(), page.Limit())
+	}
+}
+
+func TestPaginatedResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []int
+		total   int64
+		page    Page
+		hasNext bool
+	}{
+		{"first page", []int{1, 2}, 5, Page{Number: 1, Size: 2}, true},
+		{"last full page", []int{5, 6}, 6, Page{Number: 3, Size: 2}, false},
+		{"last partial page", []int{5}, 5, Page{Number: 3, Size: 2}, false},
+		{"empty page", nil, 0, Page{Number: 1, Size: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newPaginationContext("")
+			PaginatedResponse(c, tt.items, tt.total, tt.page)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", w.Code)
+			}
+
+			var body struct {
+				Items    []int `json:"items"`
+				Total    int64 `json:"total"`
+				Page     int   `json:"page"`
+				PageSize int   `json:"page_size"`
+				HasNext  bool  `json:"has_next"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Items == nil {
+				t.Errorf("Expected items to be [], got %s", w.Body.String())
+			}
+			if body.Total != tt.total || body.Page != tt.page.Number || body.PageSize != tt.page.Size {
+				t.Errorf("Unexpected envelope: %s", w.Body.String())
+			}
+			if body.HasNext != tt.hasNext {
+				t.Errorf("Expected has_next %v, got %v", tt.hasNext, body.HasNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// realIPKey is the gin context key of the client IP resolved by RealIP
+const realIPKey = "utils.realIP"
+
+// RealIP returns a middleware resolving the client IP behind the proxies in trustedCIDRs
+// (e.g. "10.0.0.0/8" or a single "192.0.2.1"). Unless the direct peer is trusted, its address
+// is the client IP. Otherwise the RFC 7239 Forwarded header, or X-Forwarded-For without it, is
+// walked from right to left skipping trusted addresses, and the first untrusted one is the
+// client. Headers that cannot be parsed fall back to the peer address, as do invalid CIDRs,
+// which are logged and ignored. Read the result with ClientIP.
+func RealIP(trustedCIDRs []string) gin.HandlerFunc {
+	log := logrus.WithFields(map[string]interface{}{
+		"module": "realip",
+	})
+
+	var trusted []netip.Prefix
+	for _, cidr := range trustedCIDRs {
+		prefix, err := parseTrustedCIDR(cidr)
+		if err != nil {
+			log.Warnf("Ignoring invalid trusted proxy %q: %v", cidr, err)
+			continue
+		}
+		trusted = append(trusted, prefix)
+	}
+
+	return func(c *gin.Context) {
+		c.Set(realIPKey, resolveClientIP(c.Request.RemoteAddr, c.Request.Header.Values("Forwarded"),
+			c.Request.Header.Values("X-Forwarded-For"), trusted))
+		c.Next()
+	}
+}
+
+// ClientIP returns the client IP resolved by RealIP, or the peer address without the middleware.
+// Forwarding headers are only honored through RealIP's trusted proxies, unlike gin's
+// c.ClientIP(), which trusts them from any peer unless trusted proxies are configured.
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString(realIPKey); ip != "" {
+		return ip
+	}
+	return c.RemoteIP()
+}
+
+// parseTrustedCIDR parses a CIDR or a single address
+func parseTrustedCIDR(cidr string) (netip.Prefix, error) {
+	if !strings.Contains(cidr, "/") {
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// resolveClientIP returns the first untrusted address walking the proxy chain back from the peer
+func resolveClientIP(remoteAddr string, forwarded, xForwardedFor []string, trusted []netip.Prefix) string {
+	peer, ok := parseHostAddr(remoteAddr)
+	if !ok {
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err != nil {
+			return remoteAddr
+		}
+		return host
+	}
+	if !isTrusted(peer, trusted) {
+		return peer.String()
+	}
+
+	var chain []string
+	var parsed bool
+	if len(forwarded) > 0 {
+		chain, parsed = forwardedFor(forwarded)
+	} else {
+		chain, parsed = xForwardedForChain(xForwardedFor), true
+	}
+	if !parsed || len(chain) == 0 {
+		return peer.String()
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseHostAddr(chain[i])
+		if !ok {
+			// an unknown or obfuscated hop cannot be attributed, fail closed
+			return peer.String()
+		}
+		client = addr
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// xForwardedForChain splits X-Forwarded-For headers into the addresses in order
+func xForwardedForChain(headers []string) []string {
+	var chain []string
+	for _, header := range headers {
+		for _, part := range strings.Split(header, ",") {
+			chain = append(chain, strings.TrimSpace(part))
+		}
+	}
+	return chain
+}
+
+// forwardedFor returns the for= values of RFC 7239 Forwarded headers in order, and false if an
+// element has no for= parameter or a malformed one
+func forwardedFor(headers []string) ([]string, bool) {
+	var chain []string
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			var value string
+			found := false
+			for _, pair := range strings.Split(element, ";") {
+				key, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					value, found = strings.Trim(v, `"`), true
+				}
+			}
+			if !found {
+				return nil, false
+			}
+			chain = append(chain, value)
+		}
+	}
+	return chain, true
+}
+
+// parseHostAddr parses an address with optional port, IPv6 optionally in brackets
+func parseHostAddr(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// isTrusted reports whether addr is in one of the trusted prefixes
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
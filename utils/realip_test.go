@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.1"}
+	prefixes := make([]netip.Prefix, 0, len(trusted))
+	for _, cidr := range trusted {
+		prefix, err := parseTrustedCIDR(cidr)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", cidr, err)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		xff       []string
+		want      string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.7:1234", nil, []string{"1.1.1.1"}, "203.0.113.7"},
+		{"untrusted peer ignores Forwarded", "203.0.113.7:1234", []string{"for=1.1.1.1"}, nil, "203.0.113.7"},
+		{"trusted peer without headers", "10.0.0.1:1234", nil, nil, "10.0.0.1"},
+		{"trusted peer with client", "10.0.0.1:1234", nil, []string{"198.51.100.9"}, "198.51.100.9"},
+		{"spoofed left entries are skipped", "10.0.0.1:1234", nil, []string{"1.1.1.1, 198.51.100.9, 10.0.0.2"}, "198.51.100.9"},
+		{"multiple headers form one chain", "10.0.0.1:1234", nil, []string{"1.1.1.1", "198.51.100.9"}, "198.51.100.9"},
+		{"all trusted returns leftmost", "10.0.0.1:1234", nil, []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"single trusted address", "192.0.2.1:80", nil, []string{"198.51.100.9"}, "198.51.100.9"},
+		{"malformed XFF entry falls back to peer", "10.0.0.1:1234", nil, []string{"1.1.1.1, not-an-ip"}, "10.0.0.1"},
+		{"empty XFF entry falls back to peer", "10.0.0.1:1234", nil, []string{"198.51.100.9, "}, "10.0.0.1"},
+		{"Forwarded wins over XFF", "10.0.0.1:1234", []string{`for=198.51.100.9;proto=https`}, []string{"1.1.1.1"}, "198.51.100.9"},
+		{"Forwarded IPv6 with port", "10.0.0.1:1234", []string{`for="[2001:db9::1]:4711"`}, nil, "2001:db9::1"},
+		{"Forwarded obfuscated falls back to peer", "10.0.0.1:1234", []string{"for=_hidden"}, nil, "10.0.0.1"},
+		{"Forwarded without for falls back to peer", "10.0.0.1:1234", []string{"proto=https"}, nil, "10.0.0.1"},
+		{"IPv6 trusted peer", "[2001:db8::1]:443", nil, []string{"2001:db9::5"}, "2001:db9::5"},
+		{"IPv6 untrusted peer", "[2001:db9::1]:443", nil, []string{"198.51.100.9"}, "2001:db9::1"},
+		{"IPv4-mapped IPv6 peer", "[::ffff:10.0.0.1]:443", nil, []string{"198.51.100.9"}, "198.51.100.9"},
+		{"peer without port", "203.0.113.7", nil, nil, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveClientIP(tt.remote, tt.forwarded, tt.xff, prefixes); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientIPWithoutMiddlewareIgnoresHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	var got string
+	engine.GET("/", func(c *gin.Context) {
+		got = ClientIP(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.7:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	req.Header.Set("X-Real-IP", "1.1.1.1")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "203.0.113.7" {
+		t.Errorf("Expected the peer address without RealIP, got %s", got)
+	}
+
+	engine = gin.New()
+	engine.Use(RealIP([]string{"203.0.113.0/24"}))
+	engine.GET("/", func(c *gin.Context) {
+		got = ClientIP(c)
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	if got != "1.1.1.1" {
+		t.Errorf("Expected the forwarded address behind a trusted proxy, got %s", got)
+	}
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/letusgogo/quick/config"
+)
+
+// StringRedacted is String with sensitive values masked, so structs holding credentials can be
+// logged. A field is sensitive if it is tagged `sensitive:"true"`, its name or json name looks
+// sensitive to config.IsSensitiveKey (password, secret, token, API key...), or it equals one of
+// redactFields (case-insensitive). Map entries are matched by key the same way. Sensitive
+// strings become "******", other sensitive values their zero value. Nested structs, pointers,
+// slices and maps are walked, a pointer cycle is cut by rendering the repeated pointer as null;
+// m itself is not modified. A nil m renders as null.
+func StringRedacted[T any](m *T, redactFields ...string) string {
+	if m == nil {
+		return "null"
+	}
+	r := &redactor{fields: redactFields, visiting: make(map[visit]bool)}
+	redacted := r.copy(reflect.ValueOf(m).Elem())
+
+	b, err := json.Marshal(redacted.Interface())
+	if err != nil {
+		return fmt.Sprintf("%+v", redacted.Interface())
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "    "); err != nil {
+		return fmt.Sprintf("%+v", redacted.Interface())
+	}
+	return out.String()
+}
+
+// visit identifies a pointer, map or slice being copied
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// redactor makes redacted copies, tracking the references on the current path to cut cycles
+type redactor struct {
+	fields   []string
+	visiting map[visit]bool
+}
+
+// copy returns a deep copy of v with sensitive values masked
+func (r *redactor) copy(v reflect.Value) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return out
+		}
+		key := visit{ptr: v.Pointer(), typ: v.Type()}
+		if r.visiting[key] {
+			// a cycle, leave the zero value
+			return out
+		}
+		r.visiting[key] = true
+		defer delete(r.visiting, key)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		out.Set(r.copy(v.Elem()).Addr())
+	case reflect.Interface:
+		if !v.IsNil() {
+			out.Set(r.copy(v.Elem()))
+		}
+	case reflect.Struct:
+		// copies unexported fields too, exported ones are replaced below
+		out.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if field.Tag.Get("sensitive") == "true" || r.isSensitive(field.Name) ||
+				(jsonName != "" && r.isSensitive(jsonName)) {
+				out.Field(i).Set(maskedValue(field.Type))
+				continue
+			}
+			out.Field(i).Set(r.copy(v.Field(i)))
+		}
+	case reflect.Slice:
+		out.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.copy(v.Index(i)))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(r.copy(v.Index(i)))
+		}
+	case reflect.Map:
+		out.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			value := r.copy(iter.Value())
+			if iter.Key().Kind() == reflect.String && r.isSensitive(iter.Key().String()) {
+				value = maskedValue(v.Type().Elem())
+			}
+			out.SetMapIndex(iter.Key(), value)
+		}
+	default:
+		out.Set(v)
+	}
+	return out
+}
+
+// maskedValue returns the masked value of a sensitive field of type t
+func maskedValue(t reflect.Type) reflect.Value {
+	switch {
+	case t.Kind() == reflect.String:
+		return reflect.ValueOf(config.RedactedValue).Convert(t)
+	case t.Kind() == reflect.Interface && reflect.TypeOf(config.RedactedValue).Implements(t):
+		return reflect.ValueOf(config.RedactedValue)
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.String:
+		masked := reflect.New(t.Elem())
+		masked.Elem().Set(reflect.ValueOf(config.RedactedValue).Convert(t.Elem()))
+		return masked
+	}
+	return reflect.Zero(t)
+}
+
+// isSensitive reports whether name looks sensitive or matches one of the redact fields
+func (r *redactor) isSensitive(name string) bool {
+	if config.IsSensitiveKey(name) {
+		return true
+	}
+	for _, field := range r.fields {
+		if strings.EqualFold(name, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+type redactNode struct {
+	Name string
+	Next *redactNode
+}
+
+type redactConfig struct {
+	User       string            `json:"user"`
+	Password   string            `json:"password"`
+	PrivateKey *string           `json:"private_key"`
+	Monkey     string            `json:"monkey"`
+	Internal   string            `json:"internal" sensitive:"true"`
+	Headers    map[string]string `json:"headers"`
+	Nested     *redactConfig     `json:"nested,omitempty"`
+}
+
+func TestStringRedacted(t *testing.T) {
+	key := "-----BEGIN KEY-----"
+	cfg := &redactConfig{
+		User:       "alice",
+		Password:   "hunter2",
+		PrivateKey: &key,
+		Monkey:     "banana",
+		Internal:   "hidden",
+		Headers:    map[string]string{"X-Api-Key": "abc", "Accept": "json"},
+		Nested:     &redactConfig{User: "bob", Password: "nested-secret"},
+	}
+
+	out := StringRedacted(cfg, "accept")
+	for _, secret := range []string{"hunter2", "BEGIN KEY", "hidden", "abc", "nested-secret", `"json"`} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Expected %q to be redacted, got %s", secret, out)
+		}
+	}
+	for _, kept := range []string{"alice", "bob", "banana"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("Expected %q to be kept, got %s", kept, out)
+		}
+	}
+	if cfg.Password != "hunter2" || *cfg.PrivateKey != key {
+		t.Error("Expected the original struct to be left untouched")
+	}
+}
+
+func TestStringRedactedNilAndCycles(t *testing.T) {
+	var nilConfig *redactConfig
+	if out := StringRedacted(nilConfig); out != "null" {
+		t.Errorf("Expected null for a nil pointer, got %s", out)
+	}
+
+	a := &redactNode{Name: "a"}
+	b := &redactNode{Name: "b", Next: a}
+	a.Next = b
+	out := StringRedacted(a)
+	if !strings.Contains(out, `"b"`) || !strings.Contains(out, "null") {
+		t.Errorf("Expected the cycle to be cut after b, got %s", out)
+	}
+
+	cyclic := map[string]interface{}{"name": "root"}
+	cyclic["self"] = cyclic
+	if out := StringRedacted(&cyclic); !strings.Contains(out, "root") {
+		t.Errorf("Expected the cyclic map to render, got %s", out)
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Bounds of the runtime settings accepted by RuntimeControlHandler
+const (
+	minGCPercent = 10
+	maxGCPercent = 1000
+	// maxProcsFactor bounds GOMAXPROCS to a multiple of the CPU count
+	maxProcsFactor = 4
+)
+
+// RuntimeSettings are the runtime knobs exposed by RuntimeControlHandler
+type RuntimeSettings struct {
+	GOMAXPROCS int `json:"gomaxprocs"`
+	GCPercent  int `json:"gc_percent"`
+}
+
+// runtimeSettingsUpdate is the PUT body, absent fields are left unchanged
+type runtimeSettingsUpdate struct {
+	GOMAXPROCS *int `json:"gomaxprocs"`
+	GCPercent  *int `json:"gc_percent"`
+}
+
+// RuntimeControlHandler reads (GET) and changes (PUT) GOMAXPROCS and the GC percent of the
+// running process, for tuning during load tests without a restart. Requests must carry
+// "Authorization: Bearer <token>"; an empty token rejects every request. GOMAXPROCS is bounded
+// to 1..4x the CPU count and the GC percent to 10..1000 (disabling the GC is not allowed).
+// Changes are logged and not persisted. Mount it on the debug group:
+//
+//	server.DebugGroup().Match([]string{"GET", "PUT"}, "/runtime", utils.RuntimeControlHandler(token))
+//
+// A PUT body sets either or both values: {"gomaxprocs": 8, "gc_percent": 200}
+func RuntimeControlHandler(token string) gin.HandlerFunc {
+	log := logrus.WithFields(logrus.Fields{
+		"module": "utils",
+	})
+
+	return func(c *gin.Context) {
+		if !bearerTokenMatches(c.GetHeader("Authorization"), token) {
+			JSONError(c, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var update runtimeSettingsUpdate
+			if err := c.ShouldBindJSON(&update); err != nil {
+				JSONError(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err := validateRuntimeUpdate(update); err != nil {
+				JSONError(c, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			if update.GOMAXPROCS != nil {
+				old := runtime.GOMAXPROCS(*update.GOMAXPROCS)
+				log.Warnf("GOMAXPROCS changed from %d to %d by %s", old, *update.GOMAXPROCS, ClientIP(c))
+			}
+			if update.GCPercent != nil {
+				old := debug.SetGCPercent(*update.GCPercent)
+				log.Warnf("GC percent changed from %d to %d by %s", old, *update.GCPercent, ClientIP(c))
+			}
+		default:
+			JSONError(c, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		c.JSON(http.StatusOK, currentRuntimeSettings())
+	}
+}
+
+// validateRuntimeUpdate checks the requested values against the bounds
+func validateRuntimeUpdate(update runtimeSettingsUpdate) error {
+	if update.GOMAXPROCS != nil {
+		maxProcs := maxProcsFactor * runtime.NumCPU()
+		if *update.GOMAXPROCS < 1 || *update.GOMAXPROCS > maxProcs {
+			return fmt.Errorf("gomaxprocs must be between 1 and %d", maxProcs)
+		}
+	}
+	if update.GCPercent != nil {
+		if *update.GCPercent < minGCPercent || *update.GCPercent > maxGCPercent {
+			return fmt.Errorf("gc_percent must be between %d and %d", minGCPercent, maxGCPercent)
+		}
+	}
+	return nil
+}
+
+// currentRuntimeSettings reads the settings without changing them
+func currentRuntimeSettings() RuntimeSettings {
+	sample := []metrics.Sample{{Name: "/gc/gogc:percent"}}
+	metrics.Read(sample)
+
+	settings := RuntimeSettings{GOMAXPROCS: runtime.GOMAXPROCS(0)}
+	if sample[0].Value.Kind() == metrics.KindUint64 {
+		settings.GCPercent = int(sample[0].Value.Uint64())
+	}
+	return settings
+}
+
+// bearerTokenMatches compares the bearer token of an Authorization header in constant time
+func bearerTokenMatches(header, token string) bool {
+	if token == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveRuntimeControl sends a request to RuntimeControlHandler guarded by token "secret"
+func serveRuntimeControl(method, auth, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Match([]string{http.MethodGet, http.MethodPut, http.MethodPost}, "/runtime", RuntimeControlHandler("secret"))
+
+	req := httptest.NewRequest(method, "/runtime", strings.NewReader(body))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestRuntimeControlHandlerAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		auth string
+	}{
+		{"missing header", ""},
+		{"wrong token", "Bearer nope"},
+		{"wrong scheme", "Basic secret"},
+		{"token prefix", "Bearer secre"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serveRuntimeControl(http.MethodGet, tt.auth, ""); w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected 401, got %d", w.Code)
+			}
+		})
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/runtime", RuntimeControlHandler(""))
+	req := httptest.NewRequest(http.MethodGet, "/runtime", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an empty token to reject every request, got %d", w.Code)
+	}
+}
+
+func TestRuntimeControlHandlerGet(t *testing.T) {
+	w := serveRuntimeControl(http.MethodGet, "Bearer secret", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var settings RuntimeSettings
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to decode settings: %v", err)
+	}
+	if settings.GOMAXPROCS != runtime.GOMAXPROCS(0) {
+		t.Errorf("Expected gomaxprocs %d, got %d", runtime.GOMAXPROCS(0), settings.GOMAXPROCS)
+	}
+	if settings.GCPercent <= 0 {
+		t.Errorf("Expected a positive gc_percent, got %d", settings.GCPercent)
+	}
+}
+
+func TestRuntimeControlHandlerPut(t *testing.T) {
+	oldProcs := runtime.GOMAXPROCS(0)
+	oldPercent := debug.SetGCPercent(100)
+	defer runtime.GOMAXPROCS(oldProcs)
+	defer debug.SetGCPercent(oldPercent)
+
+	w := serveRuntimeControl(http.MethodPut, "Bearer secret", `{"gomaxprocs": 1, "gc_percent": 250}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var settings RuntimeSettings
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to decode settings: %v", err)
+	}
+	if settings.GOMAXPROCS != 1 || runtime.GOMAXPROCS(0) != 1 {
+		t.Errorf("Expected gomaxprocs 1, got %d", settings.GOMAXPROCS)
+	}
+	if settings.GCPercent != 250 {
+		t.Errorf("Expected gc_percent 250, got %d", settings.GCPercent)
+	}
+
+	// absent fields are left unchanged
+	w = serveRuntimeControl(http.MethodPut, "Bearer secret", `{"gc_percent": 150}`)
+	if err := json.Unmarshal(w.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to decode settings: %v", err)
+	}
+	if settings.GOMAXPROCS != 1 || settings.GCPercent != 150 {
+		t.Errorf("Expected gomaxprocs 1 and gc_percent 150, got %+v", settings)
+	}
+}
+
+func TestRuntimeControlHandlerRejectsInvalidUpdates(t *testing.T) {
+	oldProcs := runtime.GOMAXPROCS(0)
+	oldPercent := debug.SetGCPercent(100)
+	defer runtime.GOMAXPROCS(oldProcs)
+	defer debug.SetGCPercent(oldPercent)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed json", `{"gomaxprocs":`},
+		{"wrong type", `{"gomaxprocs": "many"}`},
+		{"zero procs", `{"gomaxprocs": 0}`},
+		{"too many procs", `{"gomaxprocs": 1000000}`},
+		{"gc disabled", `{"gc_percent": -1}`},
+		{"gc percent too low", `{"gc_percent": 5}`},
+		{"gc percent too high", `{"gc_percent": 5000}`},
+		{"one invalid value", `{"gomaxprocs": 1, "gc_percent": 5}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveRuntimeControl(http.MethodPut, "Bearer secret", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400, got %d", w.Code)
+			}
+			if runtime.GOMAXPROCS(0) != oldProcs {
+				t.Errorf("Expected gomaxprocs to stay %d, got %d", oldProcs, runtime.GOMAXPROCS(0))
+			}
+			if percent := debug.SetGCPercent(100); percent != 100 {
+				t.Errorf("Expected gc percent to stay 100, got %d", percent)
+			}
+		})
+	}
+}
+
+func TestRuntimeControlHandlerMethodNotAllowed(t *testing.T) {
+	if w := serveRuntimeControl(http.MethodPost, "Bearer secret", "{}"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequest is a request whose latency exceeded the recorder threshold
+type SlowRequest struct {
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs float64       `json:"latency_ms"`
+	Time      time.Time     `json:"time"`
+}
+
+// SlowRequestRecorder keeps the most recent slow requests in a bounded ring buffer
+type SlowRequestRecorder struct {
+	threshold time.Duration
+	mu        sync.Mutex
+	buf       []SlowRequest
+	next      int
+	full      bool
+}
+
+// NewSlowRequestRecorder creates a recorder keeping at most size requests slower than threshold
+func NewSlowRequestRecorder(threshold time.Duration, size int) *SlowRequestRecorder {
+	if size <= 0 {
+		size = 100
+	}
+	return &SlowRequestRecorder{
+		threshold: threshold,
+		buf:       make([]SlowRequest, size),
+	}
+}
+
+// Middleware records requests slower than the threshold
+func (r *SlowRequestRecorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+		if latency < r.threshold {
+			return
+		}
+		r.add(SlowRequest{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			Latency:   latency,
+			LatencyMs: float64(latency) / float64(time.Millisecond),
+			Time:      start,
+		})
+	}
+}
+
+func (r *SlowRequestRecorder) add(req SlowRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf[r.next] = req
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Requests returns the recorded slow requests, slowest first
+func (r *SlowRequestRecorder) Requests() []SlowRequest {
+	r.mu.Lock()
+	n := r.next
+	if r.full {
+		n = len(r.buf)
+	}
+	requests := make([]SlowRequest, n)
+	copy(requests, r.buf[:n])
+	r.mu.Unlock()
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Latency > requests[j].Latency
+	})
+	return requests
+}
+
+// Handler dumps the recorded slow requests as JSON, mount it on the debug group:
+//
+//	recorder := utils.NewSlowRequestRecorder(500*time.Millisecond, 100)
+//	server.GinEngine().Use(recorder.Middleware())
+//	server.DebugGroup().GET("/slow-requests", recorder.Handler())
+func (r *SlowRequestRecorder) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"threshold_ms": float64(r.threshold) / float64(time.Millisecond),
+			"requests":     r.Requests(),
+		})
+	}
+}
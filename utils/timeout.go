@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WithTimeout runs fn as a named step bounded by d, logging start, finish and duration.
+// fn's context is cancelled when d elapses or ctx is done; WithTimeout then returns
+// without waiting for fn, with an error wrapping context.DeadlineExceeded on timeout.
+func WithTimeout(ctx context.Context, name string, d time.Duration, fn func(ctx context.Context) error) error {
+	log := logrus.WithFields(logrus.Fields{
+		"module": "utils",
+		"step":   name,
+	})
+
+	stepCtx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	start := time.Now()
+	log.Infof("%s started, timeout %s", name, d)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fn(stepCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-stepCtx.Done():
+		err = stepCtx.Err()
+	}
+	duration := time.Since(start)
+
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		err = fmt.Errorf("%s timed out after %s: %w", name, d, context.DeadlineExceeded)
+	}
+	if err != nil {
+		log.WithField("duration", duration).Errorf("%s failed: %v", name, err)
+		return err
+	}
+
+	log.WithField("duration", duration).Infof("%s finished", name)
+	return nil
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionInfo describes the running build
+type VersionInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// NewVersionInfo creates the version info of the running binary. The git commit and build date
+// are taken from the vcs stamps of the build info; set them from ldflags vars to override:
+//
+//	info := utils.NewVersionInfo("myapp", version)
+//	info.GitCommit, info.BuildDate = commit, date
+func NewVersionInfo(name, version string) VersionInfo {
+	info := VersionInfo{
+		Name:      name,
+		Version:   version,
+		GoVersion: runtime.Version(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.GitCommit = setting.Value
+			case "vcs.time":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// AddVersionEndpoint serves info as JSON on path, e.g. "/version". The payload never changes
+// while the process runs, so it is encoded once and served with an ETag for conditional requests.
+func (h *GinService) AddVersionEndpoint(path string, info VersionInfo) {
+	body, _ := json.Marshal(info)
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	h.ginEngine.GET(path, func(c *gin.Context) {
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "no-cache")
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	})
+}